@@ -0,0 +1,160 @@
+package patree
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+)
+
+// AccessLogOptions represents options of AccessLog.
+type AccessLogOptions struct {
+	// Logger is the logger of access logs. If it's nil, a logger that writes
+	// to Output is created.
+	Logger *slog.Logger
+
+	// Output is the writer of the created logger. It's os.Stderr if nil.
+	Output io.Writer
+
+	// JSON makes the created logger write JSON lines rather than text.
+	JSON bool
+
+	// Redact is the names of params whose values are replaced with
+	// "[REDACTED]", e.g. "token".
+	Redact []string
+
+	// Fields are the names of logged fields.
+	Fields AccessLogFields
+}
+
+// AccessLogFields are the names of fields of access logs. Empty names are the
+// default ones, and "-" omits the field.
+type AccessLogFields struct {
+	Method   string // "method"
+	Path     string // "path"
+	Pattern  string // "pattern"
+	Params   string // "params"
+	Status   string // "status"
+	Bytes    string // "bytes"
+	Duration string // "duration"
+	Error    string // "error"
+	NotFound string // "not_found"
+}
+
+// defaultAccessLogFields are the default names of fields of access logs.
+var defaultAccessLogFields = AccessLogFields{
+	Method:   "method",
+	Path:     "path",
+	Pattern:  "pattern",
+	Params:   "params",
+	Status:   "status",
+	Bytes:    "bytes",
+	Duration: "duration",
+	Error:    "error",
+	NotFound: "not_found",
+}
+
+// AccessLog returns a middleware that logs a line for each request after the
+// following handlers serve it. A line has the method, path, matched pattern,
+// params, status, number of bytes written, duration, Context.Err and whether
+// Context.NotFound is true. Requests that set Context.Err are logged at the
+// error level, and the others at the info level. Use it before any other
+// handler, so that it captures the responses of every handler including the
+// NotFound, MethodNotAllowed and OnError handlers.
+func AccessLog(opts AccessLogOptions) HandlerFunc {
+	logger := opts.Logger
+	if logger == nil {
+		out := opts.Output
+		if out == nil {
+			out = os.Stderr
+		}
+		if opts.JSON {
+			logger = slog.New(slog.NewJSONHandler(out, nil))
+		} else {
+			logger = slog.New(slog.NewTextHandler(out, nil))
+		}
+	}
+	fields := opts.Fields.withDefaults()
+	redact := make(map[string]bool, len(opts.Redact))
+	for _, name := range opts.Redact {
+		redact[name] = true
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *Context) {
+		start := time.Now()
+		sw := c.StatusWriter(w)
+		c.Next(sw, r)
+
+		status := sw.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := make([]slog.Attr, 0, 9)
+		add := func(name string, v slog.Value) {
+			if name != "-" {
+				attrs = append(attrs, slog.Attr{Key: name, Value: v})
+			}
+		}
+		add(fields.Method, slog.StringValue(r.Method))
+		add(fields.Path, slog.StringValue(r.URL.Path))
+		add(fields.Pattern, slog.StringValue(c.Pattern()))
+		add(fields.Params, paramsValue(c.Params, redact))
+		add(fields.Status, slog.IntValue(status))
+		add(fields.Bytes, slog.Int64Value(sw.Bytes))
+		add(fields.Duration, slog.DurationValue(time.Since(start)))
+		if c.Err != nil {
+			add(fields.Error, slog.StringValue(c.Err.Error()))
+		}
+		add(fields.NotFound, slog.BoolValue(c.NotFound()))
+
+		level := slog.LevelInfo
+		if c.Err != nil {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	}
+}
+
+// withDefaults returns the fields with the default names of empty ones.
+func (f AccessLogFields) withDefaults() AccessLogFields {
+	d := defaultAccessLogFields
+	for _, p := range []struct {
+		name *string
+		def  string
+	}{
+		{&f.Method, d.Method},
+		{&f.Path, d.Path},
+		{&f.Pattern, d.Pattern},
+		{&f.Params, d.Params},
+		{&f.Status, d.Status},
+		{&f.Bytes, d.Bytes},
+		{&f.Duration, d.Duration},
+		{&f.Error, d.Error},
+		{&f.NotFound, d.NotFound},
+	} {
+		if *p.name == "" {
+			*p.name = p.def
+		}
+	}
+	return f
+}
+
+// paramsValue returns the group value of the params sorted by their names.
+func paramsValue(params map[string]string, redact map[string]bool) slog.Value {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	attrs := make([]slog.Attr, len(names))
+	for i, name := range names {
+		v := params[name]
+		if redact[name] {
+			v = "[REDACTED]"
+		}
+		attrs[i] = slog.String(name, v)
+	}
+	return slog.GroupValue(attrs...)
+}
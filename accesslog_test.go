@@ -0,0 +1,100 @@
+package patree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "elapsed" {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+	mux := &Route{}
+	mux.Use(AccessLog(AccessLogOptions{
+		Logger: logger,
+		Redact: []string{"token"},
+		Fields: AccessLogFields{Duration: "elapsed", Bytes: "-"},
+	}))
+	mux.Get("/posts/<int:id>", featureHandler("post"))
+	mux.Get("/reset/<token>", HandlerE(func(w http.ResponseWriter, r *http.Request, c *Context) error {
+		return NewHTTPError(http.StatusGone, errors.New("token expired"))
+	}))
+	mux.NotFound(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.NotFound(w, r)
+	})
+	mux.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	})
+	mux.OnError(RenderError)
+
+	cases := []struct {
+		method, urlStr, expected string
+	}{
+		{"GET", "/posts/10", `level=INFO msg=request method=GET path=/posts/10 ` +
+			`pattern=/posts/<int:id> params.id=10 status=200 not_found=false`},
+		{"GET", "/reset/abc", `level=ERROR msg=request method=GET path=/reset/abc ` +
+			`pattern=/reset/<token> params.token=[REDACTED] status=410 ` +
+			`error="token expired" not_found=false`},
+		{"GET", "/users/10", `level=INFO msg=request method=GET path=/users/10 ` +
+			`pattern="" status=404 not_found=true`},
+		{"POST", "/posts/10", `level=INFO msg=request method=POST path=/posts/10 ` +
+			`pattern="" status=405 not_found=true`},
+	}
+	for _, tc := range cases {
+		buf.Reset()
+		mux.ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(tc.method, tc.urlStr, nil))
+		if line := strings.TrimSpace(buf.String()); line != tc.expected {
+			t.Fatalf("%s %s should log\n%s\nGot\n%s", tc.method, tc.urlStr,
+				tc.expected, line)
+		}
+	}
+}
+
+func TestAccessLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	mux := &Route{}
+	mux.Use(AccessLog(AccessLogOptions{Output: &buf, JSON: true,
+		Fields: AccessLogFields{Pattern: "route"}}))
+	mux.Get("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		io.WriteString(w, "post")
+	})
+	serve(mux, "/posts/10")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"msg": "request", "method": "GET", "path": "/posts/10",
+		"route": "/posts/<int:id>", "params": map[string]interface{}{"id": "10"},
+		"status": 200.0, "bytes": 4.0, "not_found": false,
+	}
+	for k, v := range expected {
+		if !jsonEqual(line[k], v) {
+			t.Fatalf("%s should be %v. Got %v instead", k, v, line[k])
+		}
+	}
+	if _, ok := line["duration"].(float64); !ok {
+		t.Fatalf("duration should be logged. Got %v instead", line)
+	}
+}
+
+func jsonEqual(a, b interface{}) bool {
+	x, _ := json.Marshal(a)
+	y, _ := json.Marshal(b)
+	return bytes.Equal(x, y)
+}
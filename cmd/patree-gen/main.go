@@ -0,0 +1,328 @@
+// Command patree-gen generates typed route helpers from a route file, which is
+// a JSON, YAML or TOML file that patree.RouteLoader loads. For every route, it
+// generates a URL builder function, and a param struct with a parse function
+// if the route has params, so that renaming a param breaks the build rather
+// than failing at runtime. For example, a route
+//
+//	{"name": "postComment", "pattern": "/posts/<int:id>/comments/<int:comment_id>", ...}
+//
+// generates
+//
+//	func PostCommentURL(id int64, commentID int64) string
+//	type PostCommentParams struct { ID int64; CommentID int64 }
+//	func ParsePostCommentParams(params map[string]string) (PostCommentParams, error)
+//
+// Params of "int" and "snowflake" types are int64, params of "date" type are
+// time.Time and the others are string. Params of "path" type are escaped
+// keeping slashes.
+//
+// Usage with go generate:
+//
+//	//go:generate patree-gen -f routes.json -o routes_gen.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/smagch/patree"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stderr))
+}
+
+// run generates the code and returns the exit code.
+func run(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("patree-gen", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	filename := flags.String("f", "routes.json", "route file")
+	output := flags.String("o", "routes_gen.go", "output file")
+	pkg := flags.String("package", os.Getenv("GOPACKAGE"),
+		"package name of the output file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *pkg == "" {
+		fmt.Fprintln(stderr, "-package is required outside of go generate")
+		return 2
+	}
+
+	data, err := os.ReadFile(*filename)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	specs, err := patree.DecodeRoutes(*filename, data)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	src, err := generate(*pkg, *filename, specs)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// param is a param of a route.
+type param struct {
+	name      string // param name in the pattern
+	matchType string
+	arg       string // argument name of the URL builder
+	field     string // field name of the param struct
+}
+
+// goType returns the Go type of the param.
+func (p param) goType() string {
+	switch p.matchType {
+	case "int", "snowflake":
+		return "int64"
+	case "date":
+		return "time.Time"
+	}
+	return "string"
+}
+
+// format returns an expression that formats the argument.
+func (p param) format() string {
+	switch p.goType() {
+	case "int64":
+		return "strconv.FormatInt(" + p.arg + ", 10)"
+	case "time.Time":
+		return p.arg + `.Format("2006-01-02")`
+	}
+	// slashes of paths are kept
+	if p.matchType == "path" {
+		return "(&url.URL{Path: " + p.arg + "}).EscapedPath()"
+	}
+	return "url.PathEscape(" + p.arg + ")"
+}
+
+// parse returns statements that parse the param into the field of p.
+func (p param) parse() string {
+	v := fmt.Sprintf("params[%q]", p.name)
+	switch p.goType() {
+	case "int64":
+		return fmt.Sprintf("if p.%s, err = strconv.ParseInt(%s, 10, 64); "+
+			"err != nil {\nreturn p, err\n}\n", p.field, v)
+	case "time.Time":
+		return fmt.Sprintf("if p.%s, err = time.Parse(\"2006-01-02\", %s); "+
+			"err != nil {\nreturn p, err\n}\n", p.field, v)
+	}
+	return fmt.Sprintf("p.%s = %s\n", p.field, v)
+}
+
+// route is a route to generate helpers.
+type route struct {
+	name    string
+	pattern string
+	parts   []string // escaped static patterns and param expressions
+	params  []param
+}
+
+// newRoute parses the pattern of the route declaration.
+func newRoute(spec patree.RouteSpec) (*route, error) {
+	name := spec.Name
+	if name == "" {
+		name = spec.Handler
+	}
+	if name == "" {
+		return nil, errors.New("name or handler is required")
+	}
+	// match types are not checked since custom match types are generated as
+	// string params
+	patterns, err := patree.SplitPath(spec.Pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(patterns) == 0 {
+		return nil, errors.New("pattern is required")
+	}
+
+	r := &route{name: exportedName(name), pattern: spec.Pattern}
+	for _, pat := range patterns {
+		matchType, name, ok := patree.ParseMatchPattern(pat)
+		if !ok {
+			u := &url.URL{Path: pat}
+			r.parts = append(r.parts, fmt.Sprintf("%q", u.EscapedPath()))
+			continue
+		}
+
+		p := param{matchType: matchType, name: name}
+		p.field = exportedName(p.name)
+		p.arg = unexportedName(p.name)
+		for _, q := range r.params {
+			if q.name == p.name {
+				return nil, errors.New("duplicate param: " + p.name)
+			}
+		}
+		r.params = append(r.params, p)
+		r.parts = append(r.parts, p.format())
+	}
+	return r, nil
+}
+
+// generate returns the formatted source of route helpers.
+func generate(pkg, filename string, specs []patree.RouteSpec) ([]byte, error) {
+	var routes []*route
+	names := make(map[string]*route)
+	for _, spec := range specs {
+		r, err := newRoute(spec)
+		if err != nil {
+			return nil, &patree.LoadError{File: filename, Line: spec.Line,
+				Pattern: spec.Pattern, Err: err}
+		}
+		if same := names[r.name]; same != nil {
+			if same.pattern == r.pattern {
+				continue
+			}
+			err := fmt.Errorf("route name %s is already used by %q", r.name,
+				same.pattern)
+			return nil, &patree.LoadError{File: filename, Line: spec.Line,
+				Pattern: spec.Pattern, Err: err}
+		}
+		names[r.name] = r
+		routes = append(routes, r)
+	}
+
+	imports := make(map[string]bool)
+	var body bytes.Buffer
+	for _, r := range routes {
+		var args []string
+		for _, p := range r.params {
+			args = append(args, p.arg+" "+p.goType())
+			switch p.goType() {
+			case "int64":
+				imports["strconv"] = true
+			case "time.Time":
+				imports["time"] = true
+			default:
+				imports["net/url"] = true
+			}
+		}
+
+		fmt.Fprintf(&body, "\n// %sURL returns the url of %q.\n", r.name, r.pattern)
+		fmt.Fprintf(&body, "func %sURL(%s) string {\nreturn %s\n}\n", r.name,
+			strings.Join(args, ", "), strings.Join(r.parts, " + "))
+		if len(r.params) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(&body, "\n// %sParams are the params of %q.\n", r.name, r.pattern)
+		fmt.Fprintf(&body, "type %sParams struct {\n", r.name)
+		for _, p := range r.params {
+			fmt.Fprintf(&body, "%s %s\n", p.field, p.goType())
+		}
+		fmt.Fprintf(&body, "}\n")
+
+		fmt.Fprintf(&body, "\n// Parse%[1]sParams parses the params of %[2]q, "+
+			"e.g. Context.Params.\n", r.name, r.pattern)
+		fmt.Fprintf(&body, "func Parse%[1]sParams(params map[string]string) "+
+			"(%[1]sParams, error) {\nvar p %[1]sParams\n", r.name)
+		var parses bytes.Buffer
+		for _, p := range r.params {
+			parses.WriteString(p.parse())
+		}
+		if bytes.Contains(parses.Bytes(), []byte("err =")) {
+			body.WriteString("var err error\n")
+		}
+		body.Write(parses.Bytes())
+		body.WriteString("return p, nil\n}\n")
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by patree-gen from %s. DO NOT EDIT.\n\n",
+		strings.ReplaceAll(filename, `\`, "/"))
+	fmt.Fprintf(&src, "package %s\n", pkg)
+	if len(imports) != 0 {
+		src.WriteString("\nimport (\n")
+		for _, path := range []string{"net/url", "strconv", "time"} {
+			if imports[path] {
+				fmt.Fprintf(&src, "%q\n", path)
+			}
+		}
+		src.WriteString(")\n")
+	}
+	src.Write(body.Bytes())
+	return format.Source(src.Bytes())
+}
+
+// importedNames are the package names that generated code may import, which
+// arguments must not shadow.
+var importedNames = map[string]bool{"url": true, "strconv": true, "time": true}
+
+// initialisms are words that are written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "html": true, "http": true, "id": true, "ip": true,
+	"json": true, "uri": true, "url": true, "uuid": true, "ulid": true,
+}
+
+// words splits the name into words by '_', '-' and lower to upper case
+// boundaries.
+func words(name string) []string {
+	var ws []string
+	var w []rune
+	for _, r := range name {
+		if r == '_' || r == '-' || (unicode.IsUpper(r) && len(w) != 0 &&
+			unicode.IsLower(w[len(w)-1])) {
+			if len(w) != 0 {
+				ws = append(ws, string(w))
+			}
+			w = nil
+		}
+		if r != '_' && r != '-' {
+			w = append(w, r)
+		}
+	}
+	if len(w) != 0 {
+		ws = append(ws, string(w))
+	}
+	return ws
+}
+
+// exportedName returns the upper camel case of the name.
+func exportedName(name string) string {
+	var s string
+	for _, w := range words(name) {
+		lower := strings.ToLower(w)
+		if initialisms[lower] {
+			s += strings.ToUpper(w)
+		} else {
+			s += strings.ToUpper(w[:1]) + w[1:]
+		}
+	}
+	return s
+}
+
+// unexportedName returns the lower camel case of the name. Go keywords and
+// names of the imported packages get a "Param" suffix.
+func unexportedName(name string) string {
+	ws := words(name)
+	if len(ws) == 0 {
+		return "param"
+	}
+	s := strings.ToLower(ws[0])
+	if len(ws) > 1 {
+		s += exportedName(strings.Join(ws[1:], "_"))
+	}
+	if token.IsKeyword(s) || importedNames[s] {
+		s += "Param"
+	}
+	return s
+}
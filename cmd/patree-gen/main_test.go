@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/smagch/patree"
+)
+
+func TestGenerate(t *testing.T) {
+	output := filepath.Join(t.TempDir(), "routes_gen.go")
+	var stderr bytes.Buffer
+	args := []string{"-f", "testdata/routes.json", "-o", output,
+		"-package", "routes"}
+	if code := run(args, &stderr); code != 0 {
+		t.Fatalf("patree-gen should exit with 0. Got %d instead: %s", code,
+			stderr.String())
+	}
+
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, err := os.ReadFile("testdata/routes_gen.golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, expected) {
+		t.Fatalf("generated code should be\n%s\nGot\n%s\ninstead", expected, src)
+	}
+}
+
+func TestGenerateError(t *testing.T) {
+	cases := []struct {
+		specs []patree.RouteSpec
+		msg   string
+	}{
+		{[]patree.RouteSpec{
+			{Pattern: "/posts/<int:id>", Handler: "post", Line: 1},
+			{Pattern: "/posts/<slug>", Handler: "post", Line: 2},
+		}, `routes.json:2: pattern "/posts/<slug>": route name Post is already used by "/posts/<int:id>"`},
+		{[]patree.RouteSpec{
+			{Pattern: "/posts/<int:id>/<id>", Handler: "post", Line: 3},
+		}, `routes.json:3: pattern "/posts/<int:id>/<id>": duplicate param: id`},
+		{[]patree.RouteSpec{
+			{Pattern: "/posts/<int:id", Handler: "post", Line: 1},
+		}, `routes.json:1: pattern "/posts/<int:id": ` + patree.NoClosingBracket.Error()},
+		{[]patree.RouteSpec{{Pattern: "/posts", Line: 1}},
+			`routes.json:1: pattern "/posts": name or handler is required`},
+	}
+	for _, tc := range cases {
+		_, err := generate("routes", "routes.json", tc.specs)
+		if err == nil || err.Error() != tc.msg {
+			t.Fatalf("Expected error is %s. Got %v instead", tc.msg, err)
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	cases := []struct {
+		name, exported, unexported string
+	}{
+		{"id", "ID", "id"},
+		{"comment_id", "CommentID", "commentID"},
+		{"postComment", "PostComment", "postComment"},
+		{"user-file", "UserFile", "userFile"},
+		{"api_url", "APIURL", "apiURL"},
+		{"type", "Type", "typeParam"},
+		{"url", "URL", "urlParam"},
+		{"time", "Time", "timeParam"},
+	}
+	for _, tc := range cases {
+		if s := exportedName(tc.name); s != tc.exported {
+			t.Fatalf("exported name of %s should be %s. Got %s instead",
+				tc.name, tc.exported, s)
+		}
+		if s := unexportedName(tc.name); s != tc.unexported {
+			t.Fatalf("unexported name of %s should be %s. Got %s instead",
+				tc.name, tc.unexported, s)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Command patree lints route files, prints pattern trees and shows which
+// pattern handles a path. A route file is a JSON, YAML or TOML file of route
+// declarations that patree.RouteLoader loads.
+//
+// Usage:
+//
+//	patree [-f routes.json] lint
+//	patree [-f routes.json] tree
+//	patree [-f routes.json] match METHOD PATH
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/smagch/patree"
+)
+
+const usage = `Usage:
+  patree [-f routes.json] lint
+  patree [-f routes.json] tree
+  patree [-f routes.json] match METHOD PATH
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run executes the subcommand and returns the exit code.
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("patree", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		io.WriteString(stderr, usage)
+		flags.PrintDefaults()
+	}
+	filename := flags.String("f", "routes.json", "route file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	args = flags.Args()
+	if len(args) == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	data, err := os.ReadFile(*filename)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	specs, err := patree.DecodeRoutes(*filename, data)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	switch args[0] {
+	case "lint":
+		if len(args) != 1 {
+			break
+		}
+		return lint(stdout, *filename, specs)
+	case "tree":
+		if len(args) != 1 {
+			break
+		}
+		mux, loaded := load(stderr, *filename, specs)
+		for _, entry := range mux.Entries() {
+			printTree(stdout, entry, 0)
+		}
+		if len(loaded) != len(specs) {
+			return 1
+		}
+		return 0
+	case "match":
+		if len(args) != 3 {
+			break
+		}
+		mux, _ := load(stderr, *filename, specs)
+		return match(stdout, mux, strings.ToUpper(args[1]), args[2])
+	}
+
+	flags.Usage()
+	return 2
+}
+
+// newLoader returns a RouteLoader that has a handler for every handler and
+// middleware name of the route declarations.
+func newLoader(specs []patree.RouteSpec) *patree.RouteLoader {
+	noop := func(w http.ResponseWriter, r *http.Request, c *patree.Context) {}
+	handlers := make(map[string]patree.HandlerFunc)
+	for _, spec := range specs {
+		if spec.Handler != "" {
+			handlers[spec.Handler] = noop
+		}
+		for _, name := range spec.Middleware {
+			handlers[name] = noop
+		}
+	}
+	return &patree.RouteLoader{Handlers: handlers}
+}
+
+// load registers the route declarations to a new Route one by one. Invalid
+// declarations are reported to w and skipped.
+func load(w io.Writer, filename string, specs []patree.RouteSpec) (*patree.Route, []patree.RouteSpec) {
+	var loaded []patree.RouteSpec
+	mux := &patree.Route{}
+	loader := newLoader(specs)
+	for _, spec := range specs {
+		err := loader.Load(mux, filename, []patree.RouteSpec{spec})
+		if err != nil {
+			fmt.Fprintln(w, err)
+			continue
+		}
+		loaded = append(loaded, spec)
+	}
+	return mux, loaded
+}
+
+// lint reports invalid declarations and shadowed routes. It returns 1 if
+// there is any problem.
+func lint(w io.Writer, filename string, specs []patree.RouteSpec) int {
+	mux, loaded := load(w, filename, specs)
+	problems := len(specs) - len(loaded)
+	for _, spec := range loaded {
+		methods := spec.Methods
+		if len(methods) == 0 {
+			methods = []string{"GET"}
+		}
+		for _, method := range methods {
+			method = strings.ToUpper(method)
+			by := shadowedBy(mux, spec.Pattern, method)
+			if by == "" {
+				continue
+			}
+			fmt.Fprintf(w, "%s:%d: pattern %q: %s is shadowed by %q\n",
+				filename, spec.Line, spec.Pattern, method, by)
+			problems++
+		}
+	}
+
+	if problems != 0 {
+		return 1
+	}
+	return 0
+}
+
+// shadowedBy returns a pattern that handles every example url of the
+// pattern instead of it. It returns an empty string if the pattern isn't
+// shadowed or there are no examples.
+func shadowedBy(mux *patree.Route, pat, method string) string {
+	var by string
+	for _, urlStr := range patree.ExampleURLs(pat) {
+		p, _, ok := mux.Match(method, urlStr)
+		if !ok || p == pat {
+			return ""
+		}
+		if by == "" {
+			by = p
+		}
+	}
+	return by
+}
+
+// printTree prints the entry and its descendants in the order they are
+// evaluated.
+func printTree(w io.Writer, entry *patree.Entry, depth int) {
+	pat := entry.Pattern()
+	if pat == "" {
+		pat = "(root)"
+	}
+	kind := entry.MatchType()
+	if kind == "" {
+		kind = "static"
+	}
+	fmt.Fprintf(w, "%s%s (%s, weight %d)", strings.Repeat("  ", depth), pat,
+		kind, entry.Weight())
+	if methods := entry.Methods(); len(methods) != 0 {
+		fmt.Fprintf(w, " %s", strings.Join(methods, " "))
+	}
+	fmt.Fprintln(w)
+
+	for _, child := range entry.Entries() {
+		printTree(w, child, depth+1)
+	}
+}
+
+// match prints the pattern and params that handle the request. It returns 1
+// if no pattern matches.
+func match(w io.Writer, mux *patree.Route, method, urlStr string) int {
+	pat, params, ok := mux.Match(method, urlStr)
+	if !ok {
+		fmt.Fprintf(w, "no pattern matches %s %s\n", method, urlStr)
+		return 1
+	}
+
+	fmt.Fprintf(w, "pattern: %s\n", pat)
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%s: %s\n", name, params[name])
+	}
+	return 0
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const routes = `[
+  {"pattern": "/posts", "methods": ["GET"], "handler": "posts"},
+  {"pattern": "/posts/<id>", "methods": ["GET"], "handler": "post"},
+  {"pattern": "/posts/<int:id>", "methods": ["GET", "DELETE"], "handler": "post"},
+  {"pattern": "/posts/2014", "methods": ["GET"], "handler": "archive"},
+  {"pattern": "/posts/<int:id>/edit", "handler": "edit", "middleware": ["auth"]},
+  {"pattern": "/users/<int:id", "handler": "user"},
+  {"pattern": "/posts", "methods": ["GET"], "handler": "posts"}
+]
+`
+
+func writeRoutes(t *testing.T, data string) string {
+	filename := filepath.Join(t.TempDir(), "routes.json")
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return filename
+}
+
+func TestRun(t *testing.T) {
+	filename := writeRoutes(t, routes)
+	dir := filepath.Dir(filename) + string(filepath.Separator)
+
+	cases := []struct {
+		args   []string
+		code   int
+		stdout string
+	}{
+		{[]string{"lint"}, 1, `routes.json:7: pattern "/users/<int:id": Invalid syntax: No closing bracket found
+routes.json:8: pattern "/posts": Duplicate Route registration
+routes.json:4: pattern "/posts/<int:id>": GET is shadowed by "/posts/<id>"
+`},
+		{[]string{"match", "get", "/posts/2014"}, 0,
+			"pattern: /posts/2014\n"},
+		{[]string{"match", "DELETE", "/posts/10"}, 0,
+			"pattern: /posts/<int:id>\nid: 10\n"},
+		{[]string{"match", "GET", "/posts/foo/bar"}, 1,
+			"no pattern matches GET /posts/foo/bar\n"},
+		{[]string{"match", "GET"}, 2, ""},
+		{[]string{"unknown"}, 2, ""},
+		{[]string{}, 2, ""},
+	}
+
+	for _, tc := range cases {
+		var stdout, stderr bytes.Buffer
+		code := run(append([]string{"-f", filename}, tc.args...), &stdout,
+			&stderr)
+		if code != tc.code {
+			t.Fatalf("%v should exit with %d. Got %d instead: %s", tc.args,
+				tc.code, code, stderr.String())
+		}
+		out := bytes.ReplaceAll(stdout.Bytes(), []byte(dir), nil)
+		if string(out) != tc.stdout {
+			t.Fatalf("%v should output\n%s\nGot\n%s\ninstead", tc.args,
+				tc.stdout, out)
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	filename := writeRoutes(t, `[
+  {"pattern": "/posts/<int:id>", "methods": ["GET"], "handler": "post"},
+  {"pattern": "/posts/2014", "handler": "archive"},
+  {"pattern": "/posts/<id>.html", "methods": ["GET"], "handler": "page"},
+  {"pattern": "/posts/<int:id>/comments", "methods": ["GET"], "handler": "comments"}
+]`)
+
+	expected := `(root) (static, weight 1000)
+  /posts/ (static, weight 1007)
+    2014 (static, weight 1004) *
+    <id>.html (default, weight 109) GET
+    <int:id> (int, weight 100) GET
+      /comments (static, weight 1009) GET
+`
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", filename, "tree"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("tree should exit with 0. Got %d instead: %s", code,
+			stderr.String())
+	}
+	if stdout.String() != expected {
+		t.Fatalf("tree should output\n%s\nGot\n%s\ninstead", expected,
+			stdout.String())
+	}
+
+	stdout.Reset()
+	stderr.Reset()
+	filename = writeRoutes(t, routes)
+	if code := run([]string{"-f", filename, "tree"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("tree should exit with 1 if a route fails to load. Got %d "+
+			"instead", code)
+	}
+	if stdout.Len() == 0 || stderr.Len() == 0 {
+		t.Fatalf("tree should print the tree and errors. Got %q and %q",
+			stdout.String(), stderr.String())
+	}
+}
+
+func TestRunYAML(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "routes.yaml")
+	data := `- pattern: /posts/<int:id>
+  methods: [GET]
+  handler: post
+- pattern: /users/<int:id
+  handler: user
+`
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run([]string{"-f", filename, "lint"}, &stdout, &stderr); code != 1 {
+		t.Fatalf("lint should exit with 1. Got %d instead: %s", code,
+			stderr.String())
+	}
+	expected := `routes.yaml:4: pattern "/users/<int:id": Invalid syntax: No closing bracket found` + "\n"
+	out := bytes.ReplaceAll(stdout.Bytes(), []byte(dir+string(filepath.Separator)), nil)
+	if string(out) != expected {
+		t.Fatalf("lint should output\n%s\nGot\n%s\ninstead", expected, out)
+	}
+}
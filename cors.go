@@ -0,0 +1,179 @@
+package patree
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSMetaKey is the key of the route metadata that has the *CORS of the
+// route, which overrides the CORS of the middleware, e.g.
+//
+//	mux.Use(cors.Handle)
+//	api := mux.WithMeta(patree.Meta{patree.CORSMetaKey: apiCORS})
+const CORSMetaKey = "cors"
+
+// CORS is the configuration of Cross-Origin Resource Sharing. Its Handle
+// method is the middleware.
+type CORS struct {
+	// AllowOrigins are the allowed origins. An origin is either exact, e.g.
+	// "https://example.com", a wildcard subdomain, e.g.
+	// "https://*.example.com", or "*" which allows any origin.
+	AllowOrigins []string
+
+	// AllowOriginRegexps are the regular expressions of allowed origins.
+	AllowOriginRegexps []*regexp.Regexp
+
+	// AllowHeaders are the allowed request headers. The headers that a
+	// preflight request asks for are allowed if it's nil.
+	AllowHeaders []string
+
+	// ExposeHeaders are the response headers that clients can read.
+	ExposeHeaders []string
+
+	// AllowCredentials allows requests with credentials such as cookies. The
+	// origin is sent back instead of "*" if it's set.
+	AllowCredentials bool
+
+	// MaxAge is how long results of preflight requests can be cached. It's
+	// omitted if zero.
+	MaxAge time.Duration
+}
+
+// Handle is the middleware that handles CORS requests. Use it before
+// registering patterns, so that it can answer preflight requests with the
+// methods that are registered to the patterns matching with the url.
+// Preflight requests of urls that no pattern matches fall through by
+// Context.Next. Other requests get CORS headers when a pattern matches, and
+// the CORS of CORSMetaKey in the metadata of the matched route is used if
+// there is one.
+func (cors *CORS) Handle(w http.ResponseWriter, r *http.Request, c *Context) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		c.Next(w, r)
+		return
+	}
+	h := w.Header()
+	h.Add("Vary", "Origin")
+
+	method := r.Header.Get("Access-Control-Request-Method")
+	if r.Method != "OPTIONS" || method == "" {
+		c.OnMatch(func(c *Context) {
+			cors.of(c.RouteMeta()).setHeaders(h, origin)
+		})
+		c.Next(w, r)
+		return
+	}
+
+	methods := c.route.allowedMethods(r.URL)
+	if len(methods) == 0 {
+		c.Next(w, r)
+		return
+	}
+	config := cors
+	if route, _ := c.route.lookup(method, r.URL); route != nil {
+		config = cors.of(route.meta)
+	}
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+	if config.setHeaders(h, origin) {
+		config.setPreflightHeaders(h, r, method, methods)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// of returns the CORS of the metadata, or cors if there is none.
+func (cors *CORS) of(meta Meta) *CORS {
+	if v, ok := meta[CORSMetaKey].(*CORS); ok && v != nil {
+		return v
+	}
+	return cors
+}
+
+// setHeaders sets the headers of both preflight and actual requests if the
+// origin is allowed. Headers of other configurations are removed, since a
+// request may fall through to another route.
+func (cors *CORS) setHeaders(h http.Header, origin string) bool {
+	h.Del("Access-Control-Allow-Origin")
+	h.Del("Access-Control-Allow-Credentials")
+	h.Del("Access-Control-Expose-Headers")
+	if !cors.allowOrigin(origin) {
+		return false
+	}
+
+	if cors.AllowCredentials || !cors.allowAnyOrigin() {
+		h.Set("Access-Control-Allow-Origin", origin)
+	} else {
+		h.Set("Access-Control-Allow-Origin", "*")
+	}
+	if cors.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+	if len(cors.ExposeHeaders) != 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposeHeaders, ", "))
+	}
+	return true
+}
+
+// setPreflightHeaders sets the headers of preflight requests. A handler that
+// matches with any method allows the requested method.
+func (cors *CORS) setPreflightHeaders(h http.Header, r *http.Request, method string, methods []string) {
+	allowed := make([]string, 0, len(methods))
+	for _, m := range methods {
+		if m == "*" {
+			m = method
+		}
+		allowed = append(allowed, m)
+	}
+	h.Set("Access-Control-Allow-Methods", strings.Join(allowed, ", "))
+
+	if cors.AllowHeaders != nil {
+		if len(cors.AllowHeaders) != 0 {
+			h.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+		}
+	} else if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+		h.Set("Access-Control-Allow-Headers", headers)
+	}
+	if cors.MaxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(cors.MaxAge.Seconds())))
+	}
+}
+
+func (cors *CORS) allowAnyOrigin() bool {
+	for _, o := range cors.AllowOrigins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// allowOrigin see if the origin matches with an allowed origin.
+func (cors *CORS) allowOrigin(origin string) bool {
+	for _, o := range cors.AllowOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+		if i := strings.Index(o, "://*."); i != -1 && matchSubdomain(origin, o[:i+3], o[i+4:]) {
+			return true
+		}
+	}
+	for _, re := range cors.AllowOriginRegexps {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSubdomain see if the origin is a subdomain of the domain with the
+// scheme, e.g. "https://api.example.com" of "https://" and ".example.com".
+func matchSubdomain(origin, scheme, domain string) bool {
+	if len(origin) <= len(scheme)+len(domain) {
+		return false
+	}
+	return strings.EqualFold(origin[:len(scheme)], scheme) &&
+		strings.EqualFold(origin[len(origin)-len(domain):], domain)
+}
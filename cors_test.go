@@ -0,0 +1,110 @@
+package patree
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCORS(t *testing.T) {
+	cors := &CORS{
+		AllowOrigins:       []string{"https://example.com", "https://*.example.com"},
+		AllowOriginRegexps: []*regexp.Regexp{regexp.MustCompile(`^http://localhost:\d+$`)},
+		ExposeHeaders:      []string{"X-Total"},
+		MaxAge:             10 * time.Minute,
+	}
+	public := &CORS{AllowOrigins: []string{"*"}, AllowHeaders: []string{}}
+	mux := &Route{}
+	mux.Use(cors.Handle)
+	mux.Get("/posts/<int:id>", featureHandler("post"))
+	mux.Delete("/posts/<int:id>", featureHandler("delete"))
+	mux.WithMeta(Meta{CORSMetaKey: public}).Get("/public", featureHandler("public"))
+	mux.Handle("/any", featureHandler("any"))
+
+	cases := []struct {
+		method  string
+		urlStr  string
+		header  map[string]string
+		code    int
+		expects map[string]string
+	}{
+		{"GET", "/posts/10", map[string]string{"Origin": "https://example.com"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": "https://example.com",
+				"Access-Control-Expose-Headers": "X-Total", "Vary": "Origin"}},
+		{"GET", "/posts/10", map[string]string{"Origin": "https://api.example.com"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": "https://api.example.com"}},
+		{"GET", "/posts/10", map[string]string{"Origin": "http://localhost:3000"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": "http://localhost:3000"}},
+		{"GET", "/posts/10", map[string]string{"Origin": "https://evil.com"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"GET", "/posts/10", map[string]string{"Origin": "https://example.com.evil.com"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": ""}},
+		{"GET", "/posts/10", nil, 200,
+			map[string]string{"Access-Control-Allow-Origin": "", "Vary": ""}},
+		{"GET", "/public", map[string]string{"Origin": "https://evil.com"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": "*",
+				"Access-Control-Expose-Headers": ""}},
+		{"OPTIONS", "/posts/10", map[string]string{"Origin": "https://example.com",
+			"Access-Control-Request-Method":  "DELETE",
+			"Access-Control-Request-Headers": "Authorization"}, 204,
+			map[string]string{"Access-Control-Allow-Origin": "https://example.com",
+				"Access-Control-Allow-Methods": "DELETE, GET, HEAD",
+				"Access-Control-Allow-Headers": "Authorization",
+				"Access-Control-Max-Age":       "600"}},
+		{"OPTIONS", "/public", map[string]string{"Origin": "https://evil.com",
+			"Access-Control-Request-Method":  "GET",
+			"Access-Control-Request-Headers": "Authorization"}, 204,
+			map[string]string{"Access-Control-Allow-Origin": "*",
+				"Access-Control-Allow-Methods": "GET, HEAD",
+				"Access-Control-Allow-Headers": "",
+				"Access-Control-Max-Age":       ""}},
+		{"OPTIONS", "/any", map[string]string{"Origin": "https://example.com",
+			"Access-Control-Request-Method": "PUT"}, 204,
+			map[string]string{"Access-Control-Allow-Methods": "PUT"}},
+		{"OPTIONS", "/posts/10", map[string]string{"Origin": "https://evil.com",
+			"Access-Control-Request-Method": "GET"}, 204,
+			map[string]string{"Access-Control-Allow-Origin": "",
+				"Access-Control-Allow-Methods": ""}},
+		{"OPTIONS", "/users/10", map[string]string{"Origin": "https://example.com",
+			"Access-Control-Request-Method": "GET"}, 200,
+			map[string]string{"Access-Control-Allow-Origin": ""}},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(tc.method, tc.urlStr, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tc.code {
+			t.Fatalf("%s %s %v should respond %d. Got %d instead", tc.method,
+				tc.urlStr, tc.header, tc.code, w.Code)
+		}
+		for k, v := range tc.expects {
+			if actual := w.Header().Get(k); actual != v {
+				t.Fatalf("%s %s %v should have %s %q. Got %q instead",
+					tc.method, tc.urlStr, tc.header, k, v, actual)
+			}
+		}
+	}
+}
+
+func TestCORSCredentials(t *testing.T) {
+	cors := &CORS{AllowOrigins: []string{"*"}, AllowCredentials: true}
+	mux := &Route{}
+	mux.Use(cors.Handle)
+	mux.Get("/me", func(w http.ResponseWriter, r *http.Request, c *Context) {})
+
+	r := httptest.NewRequest("GET", "/me", nil)
+	r.Header.Set("Origin", "https://example.com")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, r)
+	if origin := w.Header().Get("Access-Control-Allow-Origin"); origin != "https://example.com" {
+		t.Fatalf("credentials should send back the origin. Got %q instead", origin)
+	}
+	if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("credentials should be allowed. Got %v", w.Header())
+	}
+}
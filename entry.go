@@ -24,9 +24,11 @@ func newStaticEntry(pat string) *Entry {
 	return entry
 }
 
-func newMatchEntry(pat string) *Entry {
+func newMatchEntry(pat string, matchers *MatcherRegistry) *Entry {
 	entry := newEntry(pat)
-	matcher, name := parseMatcher(pat)
+	matcher, name := matchers.parseMatcher(pat)
+	entry.name, entry.matcher = name, matcher
+	entry.matchType, _ = splitMatchPattern(pat)
 	entry.exec = entry.getExecMatch(name, matcher)
 	entry.weight = 100
 	return entry
@@ -34,6 +36,7 @@ func newMatchEntry(pat string) *Entry {
 
 func newSuffixMatchEntry(pat, name string, matcher Matcher) *Entry {
 	entry := newEntry(pat)
+	entry.name, entry.matcher = name, matcher
 	entry.exec = entry.getExecMatch(name, matcher)
 	entry.weight = 100 + len(pat)
 	return entry
@@ -41,12 +44,16 @@ func newSuffixMatchEntry(pat, name string, matcher Matcher) *Entry {
 
 // Entry is a pattern node.
 type Entry struct {
-	pattern  string
-	handlers map[string]*Route
-	handler  *Route
-	entries  []*Entry
-	exec     ExecFunc
-	weight   int
+	pattern   string
+	handlers  map[string]*Route
+	handler   *Route
+	entries   []*Entry
+	exec      ExecFunc
+	weight    int
+	matchers  *MatcherRegistry
+	name      string
+	matcher   Matcher
+	matchType string
 }
 
 // Len returns a total number of child entries.
@@ -86,6 +93,64 @@ func (e *Entry) Pattern() string {
 	return e.pattern
 }
 
+// Entries returns child entries in the order they are evaluated. The returned
+// slice must not be modified.
+func (e *Entry) Entries() []*Entry {
+	return e.entries
+}
+
+// Weight returns the weight of the entry. Child entries with heavier weights
+// are evaluated first.
+func (e *Entry) Weight() int {
+	return e.weight
+}
+
+// MatchType returns the match type of the entry, e.g. "int" for "<int:id>"
+// and "<int:id>-page". It returns an empty string for static entries.
+func (e *Entry) MatchType() string {
+	return e.matchType
+}
+
+// Param returns the param name of the entry, e.g. "id" for "<int:id>". It
+// returns an empty string for static entries.
+func (e *Entry) Param() string {
+	return e.name
+}
+
+// Methods returns sorted methods that have handlers. "*" represents the
+// handler that matches with any method.
+func (e *Entry) Methods() []string {
+	var methods []string
+	for method := range e.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	if e.handler != nil {
+		methods = append(methods, "*")
+	}
+	return methods
+}
+
+// clone returns a deep copy of the entry and its child entries. Handlers are
+// shared with the copy.
+func (e *Entry) clone() *Entry {
+	c := *e
+	c.handlers = make(map[string]*Route, len(e.handlers))
+	for method, h := range e.handlers {
+		c.handlers[method] = h
+	}
+	c.entries = make([]*Entry, len(e.entries))
+	for i, child := range e.entries {
+		c.entries[i] = child.clone()
+	}
+	if c.matcher != nil {
+		c.exec = c.getExecMatch(c.name, c.matcher)
+	} else {
+		c.exec = c.execPrefix
+	}
+	return &c
+}
+
 // getChildEntry returns a child Entry that matches the given pattern string.
 func (e *Entry) getChildEntry(pat string) *Entry {
 	for _, entry := range e.entries {
@@ -99,7 +164,7 @@ func (e *Entry) getChildEntry(pat string) *Entry {
 // MergePattern add entry patterns with given pattern strings. If a pattern
 // already exists on the entry, it adds remaining patterns to the existing entry.
 func (e *Entry) MergePatterns(patterns []string) *Entry {
-	pat, size := PeekNextPattern(patterns)
+	pat, size := peekNextPattern(patterns, e.matchers)
 	if child := e.getChildEntry(pat); child != nil {
 		if len(patterns) == size {
 			return child
@@ -138,18 +203,20 @@ func (e *Entry) addPatterns(patterns []string) *Entry {
 
 	for len(patterns) > 0 {
 		var entry *Entry
-		pat, size := PeekNextPattern(patterns)
+		pat, size := peekNextPattern(patterns, e.matchers)
 
 		// suffix entry
 		if size == 2 {
-			matcher, name := parseMatcher(patterns[0])
+			matcher, name := e.matchers.parseMatcher(patterns[0])
 			suffixMatcher := &SuffixMatcher{patterns[1], matcher}
 			entry = newSuffixMatchEntry(pat, name, suffixMatcher)
+			entry.matchType, _ = splitMatchPattern(patterns[0])
 		} else if isMatchPattern(pat) {
-			entry = newMatchEntry(pat)
+			entry = newMatchEntry(pat, e.matchers)
 		} else {
 			entry = newStaticEntry(pat)
 		}
+		entry.matchers = e.matchers
 
 		currentNode.AddEntry(entry)
 		currentNode = entry
@@ -159,6 +226,38 @@ func (e *Entry) addPatterns(patterns []string) *Entry {
 	return currentNode
 }
 
+// matchPrefix returns the length of the leading string of urlStr that the
+// entry matches, or -1 if it doesn't match. matchStr is the matched string of
+// match entries.
+func (e *Entry) matchPrefix(urlStr string) (offset int, matchStr string) {
+	if e.matcher != nil {
+		return e.matcher.Match(urlStr)
+	}
+	if !strings.HasPrefix(urlStr, e.pattern) {
+		return -1, ""
+	}
+	return len(e.pattern), ""
+}
+
+// collectMethods adds the methods of the entry and its descendants that match
+// with the whole urlStr. The handler that matches with any method is added as
+// "*".
+func (e *Entry) collectMethods(urlStr string, methods map[string]bool) {
+	offset, _ := e.matchPrefix(urlStr)
+	if offset == -1 {
+		return
+	}
+	if len(urlStr) == offset {
+		for _, method := range e.Methods() {
+			methods[method] = true
+		}
+		return
+	}
+	for _, entry := range e.entries {
+		entry.collectMethods(urlStr[offset:], methods)
+	}
+}
+
 // execPrefix simply see if the given urlStr has a leading pattern.
 func (e *Entry) execPrefix(method, urlStr string) (*Route, []string) {
 	if !strings.HasPrefix(urlStr, e.pattern) {
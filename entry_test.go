@@ -65,7 +65,7 @@ func TestIntMatchEntry(t *testing.T) {
 		"139093449850284011": true,
 	}
 
-	e := newMatchEntry("<int:test_id>")
+	e := newMatchEntry("<int:test_id>", nil)
 	e.handlers["GET"] = foobarHandler
 
 	for s, ok := range cases {
@@ -150,3 +150,20 @@ func TestOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestEntryMethods(t *testing.T) {
+	e := newMatchEntry("<int:id>", nil)
+	if e.MatchType() != "int" || e.Param() != "id" {
+		t.Fatal("match entry should have its match type and param name")
+	}
+	if len(e.Methods()) != 0 {
+		t.Fatal("entry without handlers should not have methods")
+	}
+	e.SetMethodHandler("POST", foobarHandler)
+	e.SetMethodHandler("GET", foobarHandler)
+	e.SetHandler(foobarHandler)
+	expected := []string{"GET", "POST", "*"}
+	if methods := e.Methods(); !reflect.DeepEqual(methods, expected) {
+		t.Fatalf("Got %v instead of expected %v", methods, expected)
+	}
+}
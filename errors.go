@@ -0,0 +1,270 @@
+package patree
+
+import (
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// HandlerFuncE is a handler that returns an error rather than sets
+// Context.Err. It can be passed to Handle, Get, Use and the other
+// registration methods, e.g.
+//
+//	mux.Get("/posts/<int:id>", getPost)
+//
+// The returned error stops the chain, and is set to Context.Err, which is
+// handled by the handler of OnError.
+type HandlerFuncE func(http.ResponseWriter, *http.Request, *Context) error
+
+// ServeHTTPContext calls f(w, r, c) and sets the returned error to c.Err.
+func (f HandlerFuncE) ServeHTTPContext(w http.ResponseWriter, r *http.Request, c *Context) {
+	if err := f(w, r, c); err != nil {
+		c.Err = err
+	}
+}
+
+// HandlerE adapts the HandlerFuncE to a HandlerFunc, e.g. for a map of
+// HandlerFuncs of RouteLoader.
+func HandlerE(f HandlerFuncE) HandlerFunc {
+	return f.ServeHTTPContext
+}
+
+// ErrorHandlerFunc handles the error of a request.
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request, c *Context, err error)
+
+// StatusError is the interface of errors that carry a HTTP status code.
+type StatusError interface {
+	error
+	StatusCode() int
+}
+
+// HTTPError is an error with a HTTP status code.
+type HTTPError struct {
+	Status int
+	Err    error
+}
+
+// NewHTTPError returns a HTTPError of the status. The error may be nil.
+func NewHTTPError(status int, err error) *HTTPError {
+	return &HTTPError{status, err}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+// StatusCode returns the status code.
+func (e *HTTPError) StatusCode() int {
+	return e.Status
+}
+
+// Unwrap returns the underlying error.
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorStatus returns the status code of the first StatusError in the chain of
+// the error, or 500 if there is no StatusError.
+func ErrorStatus(err error) int {
+	var e StatusError
+	if errors.As(err, &e) {
+		return e.StatusCode()
+	}
+	return http.StatusInternalServerError
+}
+
+// RenderError is an ErrorHandlerFunc that responds with the status of the
+// error, e.g. mux.OnError(patree.RenderError). The response is JSON, HTML or
+// plain text by the Accept header of the request, and JSON if the header
+// prefers none of them. Messages of 5xx errors are replaced with the status
+// text so that internal errors aren't exposed. Nothing is written if
+// Context.Written reports the response has been written, which requires a
+// middleware that serves the request with Context.StatusWriter.
+func RenderError(w http.ResponseWriter, r *http.Request, c *Context, err error) {
+	if c.Written() {
+		return
+	}
+	status := ErrorStatus(err)
+	msg := err.Error()
+	if status >= 500 {
+		msg = http.StatusText(status)
+	}
+
+	h := w.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	h.Add("Vary", "Accept")
+	switch negotiate(r.Header.Get("Accept"), errorContentTypes) {
+	case "text/html":
+		h.Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(status)
+		title := strconv.Itoa(status) + " " + html.EscapeString(http.StatusText(status))
+		io.WriteString(w, "<!DOCTYPE html>\n<title>"+title+"</title>\n<h1>"+
+			title+"</h1>\n<p>"+html.EscapeString(msg)+"</p>\n")
+	case "text/plain":
+		h.Set("Content-Type", "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		io.WriteString(w, msg+"\n")
+	default:
+		h.Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{"status": status, "message": msg},
+		})
+	}
+}
+
+// errorContentTypes are content types of RenderError in the order of
+// preference.
+var errorContentTypes = []string{"application/json", "text/html", "text/plain"}
+
+// negotiate returns the offer that the Accept header prefers. The quality of
+// an offer is the one of the most specific media range that matches it. It
+// returns the first offer if the header accepts none of them.
+func negotiate(accept string, offers []string) string {
+	type mediaRange struct {
+		value string
+		q     float64
+	}
+	var ranges []mediaRange
+	for _, v := range strings.Split(accept, ",") {
+		value, params, _ := strings.Cut(v, ";")
+		r := mediaRange{strings.ToLower(strings.TrimSpace(value)), 1}
+		for _, param := range strings.Split(params, ";") {
+			k, v, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if k != "q" {
+				continue
+			}
+			if q, err := strconv.ParseFloat(v, 64); err == nil {
+				r.q = q
+			}
+		}
+		ranges = append(ranges, r)
+	}
+
+	best, bestQ := offers[0], 0.0
+	for _, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if s := mediaSpecificity(r.value, offer); s > specificity {
+				q, specificity = r.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best
+}
+
+// mediaSpecificity returns 2 if the media range is the content type, 1 if it's
+// the type with "*" subtype, 0 if it's "*/*" and -1 if it doesn't match.
+func mediaSpecificity(mediaRange, contentType string) int {
+	switch {
+	case mediaRange == contentType:
+		return 2
+	case mediaRange == "*/*":
+		return 0
+	case strings.HasSuffix(mediaRange, "/*") &&
+		strings.HasPrefix(contentType, mediaRange[:len(mediaRange)-1]):
+		return 1
+	}
+	return -1
+}
+
+// NotFound sets the handler that is called when no handler handles a request,
+// that is, Context.Next reaches the end of the chain of the route. It's called
+// by that Context.Next, so that middlewares that wrap the ResponseWriter
+// observe its response. It isn't called if MethodNotAllowed handles the
+// request.
+func (r *Route) NotFound(f HandlerFunc) {
+	r.notFound = f
+}
+
+// MethodNotAllowed sets the handler that is called instead of the NotFound
+// handler when patterns match with the request url but not with the method.
+// The Allow header is set to the allowed methods before it's called.
+func (r *Route) MethodNotAllowed(f HandlerFunc) {
+	r.methodNotAllowed = f
+}
+
+// OnError sets the handler that is called when a handler sets Context.Err or
+// a HandlerFuncE returns an error. It's called as soon as the handler returns,
+// with the ResponseWriter passed to the handler, so that the middlewares that
+// call Context.Next observe its response. Use ErrorStatus to respond with the
+// status of typed errors such as HTTPError, or RenderError as the handler.
+func (r *Route) OnError(f ErrorHandlerFunc) {
+	r.onError = f
+}
+
+// hasHooks see if the route has any of the error, method not allowed and not
+// found handlers.
+func (route *Route) hasHooks() bool {
+	return route.onError != nil || route.methodNotAllowed != nil ||
+		route.notFound != nil
+}
+
+// finish calls the error, method not allowed or not found handler of the
+// route. Only one of them is called for a request.
+func (route *Route) finish(w http.ResponseWriter, r *http.Request, c *Context) {
+	if c.finished {
+		return
+	}
+	if c.Err != nil {
+		if route.onError != nil {
+			c.finished = true
+			route.onError(w, r, c, c.Err)
+		}
+		return
+	}
+	if !c.NotFound() {
+		return
+	}
+
+	if route.methodNotAllowed != nil {
+		methods := route.allowedMethods(r.URL)
+		if isMethodNotAllowed(r.Method, methods) {
+			c.finished = true
+			w.Header().Set("Allow", strings.Join(methods, ", "))
+			route.methodNotAllowed(w, r, c)
+			return
+		}
+	}
+	if route.notFound != nil {
+		c.finished = true
+		route.notFound(w, r, c)
+	}
+}
+
+// isMethodNotAllowed see if the method isn't allowed while the others are.
+// Handlers of allowed methods may fall through by Context.Next.
+func isMethodNotAllowed(method string, allowed []string) bool {
+	for _, m := range allowed {
+		if m == method || m == "*" {
+			return false
+		}
+	}
+	return len(allowed) != 0
+}
+
+// sortedMethods returns the sorted methods of the set. "*" is the last.
+func sortedMethods(set map[string]bool) []string {
+	var methods []string
+	for method := range set {
+		if method != "*" {
+			methods = append(methods, method)
+		}
+	}
+	sort.Strings(methods)
+	if set["*"] {
+		methods = append(methods, "*")
+	}
+	return methods
+}
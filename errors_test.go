@@ -0,0 +1,278 @@
+package patree
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{errors.New("foo"), 500},
+		{NewHTTPError(404, nil), 404},
+		{fmt.Errorf("wrapped: %w", NewHTTPError(403, errors.New("forbidden"))), 403},
+	}
+	for _, tc := range cases {
+		if status := ErrorStatus(tc.err); status != tc.status {
+			t.Fatalf("%v should have status %d. Got %d instead", tc.err,
+				tc.status, status)
+		}
+	}
+
+	if err := NewHTTPError(400, nil); err.Error() != "Bad Request" {
+		t.Fatalf("HTTPError without error should have the status text. Got %s",
+			err.Error())
+	}
+}
+
+func TestErrorHandlers(t *testing.T) {
+	mux := &Route{}
+	mux.Get("/posts/<int:id>", featureHandler("post"))
+	mux.Post("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Err = NewHTTPError(http.StatusConflict, errors.New("post exists"))
+	})
+	mux.Get("/fallthrough", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Next(w, r)
+	})
+	mux.Handle("/any", featureHandler("any"))
+	mux.NotFound(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	})
+	mux.OnError(func(w http.ResponseWriter, r *http.Request, c *Context, err error) {
+		w.WriteHeader(ErrorStatus(err))
+		io.WriteString(w, err.Error())
+	})
+
+	cases := []struct {
+		method string
+		urlStr string
+		code   int
+		body   string
+		allow  string
+	}{
+		{"GET", "/posts/10", 200, "post10", ""},
+		{"POST", "/posts/10", 409, "post exists", ""},
+		{"DELETE", "/posts/10", 405, "method not allowed\n", "GET, HEAD, POST"},
+		{"GET", "/posts/foo", 404, "not found\n", ""},
+		{"GET", "/fallthrough", 404, "not found\n", ""},
+		{"POST", "/any", 200, "any", ""},
+	}
+
+	for _, tc := range cases {
+		r := httptest.NewRequest(tc.method, tc.urlStr, nil)
+		for _, h := range []http.Handler{mux, mux.Compile()} {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.code || w.Body.String() != tc.body {
+				t.Fatalf("%s %s should respond %d %q. Got %d %q instead",
+					tc.method, tc.urlStr, tc.code, tc.body, w.Code,
+					w.Body.String())
+			}
+			if allow := w.Header().Get("Allow"); allow != tc.allow {
+				t.Fatalf("%s %s should allow %q. Got %q instead", tc.method,
+					tc.urlStr, tc.allow, allow)
+			}
+		}
+	}
+}
+
+func TestAllowedMethods(t *testing.T) {
+	mux := &Route{}
+	mux.Get("/posts/<int:id>", foobar)
+	mux.Use(foobar)
+	mux.Delete("/posts/<slug>", foobar)
+	mux.Handle("/posts/2014", foobar)
+
+	cases := map[string]string{
+		"/posts/10":   "[DELETE GET HEAD]",
+		"/posts/foo":  "[DELETE]",
+		"/posts/2014": "[DELETE GET HEAD *]",
+		"/users/10":   "[]",
+	}
+	for urlStr, expected := range cases {
+		methods := fmt.Sprint(mux.AllowedMethods(urlStr))
+		if methods != expected {
+			t.Fatalf("%s should allow %s. Got %s instead", urlStr, expected,
+				methods)
+		}
+	}
+}
+
+func TestHandlerE(t *testing.T) {
+	mux := &Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) error {
+		if r.Header.Get("Authorization") == "" {
+			return NewHTTPError(http.StatusUnauthorized, errors.New("token is required"))
+		}
+		c.Next(w, r)
+		return nil
+	})
+	var getPost HandlerFuncE = func(w http.ResponseWriter, r *http.Request, c *Context) error {
+		if c.Params["id"] == "0" {
+			return errors.New("connection refused")
+		}
+		io.WriteString(w, "post"+c.Params["id"])
+		return nil
+	}
+	mux.Get("/posts/<int:id>", getPost, func(w http.ResponseWriter, r *http.Request, c *Context) {
+		t.Fatal("the chain should stop after HandlerFuncE")
+	})
+	mux.Get("/partial", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Next(c.StatusWriter(w), r)
+	}, HandlerE(func(w http.ResponseWriter, r *http.Request, c *Context) error {
+		io.WriteString(w, "partial")
+		return errors.New("connection reset")
+	}))
+	mux.OnError(RenderError)
+
+	cases := []struct {
+		urlStr string
+		header map[string]string
+		code   int
+		ctype  string
+		body   string
+	}{
+		{"/posts/10", map[string]string{"Authorization": "x"}, 200,
+			"text/plain; charset=utf-8", "post10"},
+		{"/posts/10", nil, 401, "application/json",
+			`{"error":{"message":"token is required","status":401}}` + "\n"},
+		{"/posts/10", map[string]string{"Accept": "text/html,*/*;q=0.8"}, 401,
+			"text/html; charset=utf-8", "<!DOCTYPE html>\n<title>401 Unauthorized</title>\n" +
+				"<h1>401 Unauthorized</h1>\n<p>token is required</p>\n"},
+		{"/posts/0", map[string]string{"Authorization": "x", "Accept": "text/plain"},
+			500, "text/plain; charset=utf-8", "Internal Server Error\n"},
+		{"/partial", map[string]string{"Authorization": "x"}, 200,
+			"text/plain; charset=utf-8", "partial"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", tc.urlStr, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tc.code || w.Header().Get("Content-Type") != tc.ctype ||
+			(tc.body != "" && w.Body.String() != tc.body) {
+			t.Fatalf("%s %v should respond %d %s %q. Got %d %s %q instead",
+				tc.urlStr, tc.header, tc.code, tc.ctype, tc.body, w.Code,
+				w.Header().Get("Content-Type"), w.Body.String())
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Get should panic if a handler is not a handler")
+		}
+	}()
+	mux.Get("/invalid", "handler")
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                                  "application/json",
+		"*/*":                               "application/json",
+		"text/html":                         "text/html",
+		"text/html, application/json":       "application/json",
+		"text/html;q=0.9, application/json": "application/json",
+		"text/*":                            "text/html",
+		"text/*;q=0.5, text/plain":          "text/plain",
+		"image/png":                         "application/json",
+		"application/json;q=0, */*":         "text/html",
+	}
+	for accept, expected := range cases {
+		if ctype := negotiate(accept, errorContentTypes); ctype != expected {
+			t.Fatalf("%q should prefer %s. Got %s instead", accept, expected,
+				ctype)
+		}
+	}
+}
+
+func TestErrorHandlersInChain(t *testing.T) {
+	var status int
+	mux := &Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		sw := &StatusWriter{ResponseWriter: w}
+		c.Next(sw, r)
+		status = sw.Status
+	})
+	mux.Get("/posts/<int:id>", featureHandler("post"))
+	mux.Post("/posts/<int:id>", HandlerE(func(w http.ResponseWriter, r *http.Request, c *Context) error {
+		return NewHTTPError(http.StatusConflict, errors.New("post exists"))
+	}))
+	mux.NotFound(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.Error(w, "not found", http.StatusNotFound)
+	})
+	mux.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	})
+	mux.OnError(RenderError)
+
+	cases := []struct {
+		method string
+		urlStr string
+		code   int
+	}{
+		{"GET", "/posts/10", 200},
+		{"POST", "/posts/10", 409},
+		{"DELETE", "/posts/10", 405},
+		{"GET", "/users/10", 404},
+	}
+	for _, tc := range cases {
+		status = 0
+		r := httptest.NewRequest(tc.method, tc.urlStr, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		if w.Code != tc.code || status != tc.code {
+			t.Fatalf("%s %s should respond %d through the middleware. Got %d "+
+				"and %d instead", tc.method, tc.urlStr, tc.code, w.Code, status)
+		}
+	}
+}
+
+func TestMountedErrorHandlers(t *testing.T) {
+	pages := &Route{}
+	pages.Get("/about", featureHandler("about"))
+
+	api := &Route{}
+	api.Get("/api/posts/<int:id>", featureHandler("post"))
+	api.Get("/api/fail", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Err = errors.New("fail")
+	})
+	api.NotFound(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.Error(w, "api not found", http.StatusNotFound)
+	})
+	api.OnError(func(w http.ResponseWriter, r *http.Request, c *Context, err error) {
+		http.Error(w, "api "+err.Error(), http.StatusInternalServerError)
+	})
+
+	mux := &Route{}
+	mux.UseHandler(pages)
+	mux.Get("/home", featureHandler("home"))
+	mux.UseHandler(api)
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		t.Fatalf("%s should be handled by the mounted route", r.URL)
+	})
+
+	cases := map[string]string{
+		"/about":        "about",
+		"/home":         "home",
+		"/api/posts/10": "post10",
+		"/api/fail":     "api fail\n",
+		"/users":        "api not found\n",
+	}
+	for urlStr, body := range cases {
+		if w := serve(mux, urlStr); w.Body.String() != body {
+			t.Fatalf("%s should respond %q. Got %q instead", urlStr, body,
+				w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package patree
+
+// exampleParams are example params of the built-in match types.
+var exampleParams = map[string][]string{
+	"default":      {"x", "slug", "123"},
+	"int":          {"1", "42", "2000"},
+	"hex":          {"a", "ff", "1f2e"},
+	"uuid":         {"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+	"uuid4":        {"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+	"uuid7":        {"01890a5d-ac96-774b-bcce-b302099a8057"},
+	"uuid_lower":   {"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+	"uuid_compact": {"be567c9c63924f6db5aee35893f956bb"},
+	"date":         {"2014-01-01", "1999-12-31"},
+	"ulid":         {"01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+	"ksuid":        {"0ujtsYcgvSTl8PAuAdqWYSMnLOv"},
+	"objectid":     {"507f1f77bcf86cd799439011"},
+	"snowflake":    {"1541815603606036480", "42"},
+	"base64url":    {"dG9rZW4", "x-_y"},
+	"semver":       {"1.0.0", "2.1.0-rc.1"},
+	"path":         {"x", "a/b.txt", "a/b/"},
+}
+
+// ExampleURLs returns example urls that match with the pattern, e.g. to find
+// patterns that shadow others. It returns nil if the pattern is invalid or
+// has a match type that isn't built in, e.g. the one of a MatcherRegistry.
+func ExampleURLs(pat string) []string {
+	patterns, err := SplitPath(pat)
+	if err != nil {
+		return nil
+	}
+
+	urls := make([]string, 3)
+	for _, p := range patterns {
+		if !isMatchPattern(p) {
+			for i := range urls {
+				urls[i] += p
+			}
+			continue
+		}
+
+		matchType, _ := splitMatchPattern(p)
+		values := exampleParams[matchType]
+		if values == nil {
+			return nil
+		}
+		for i := range urls {
+			urls[i] += values[i%len(values)]
+		}
+	}
+	return urls
+}
@@ -0,0 +1,36 @@
+package patree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExampleParams(t *testing.T) {
+	for matchType, m := range MatcherMap {
+		values := exampleParams[matchType]
+		if len(values) == 0 {
+			t.Fatalf("%s should have example params", matchType)
+		}
+		for _, v := range values {
+			if offset, _ := m.Match(v); offset != len(v) {
+				t.Fatalf("%s should match with its example %s", matchType, v)
+			}
+		}
+	}
+}
+
+func TestExampleURLs(t *testing.T) {
+	cases := map[string][]string{
+		"/posts/<int:id>":     {"/posts/1", "/posts/42", "/posts/2000"},
+		"/posts/<id>.json":    {"/posts/x.json", "/posts/slug.json", "/posts/123.json"},
+		"/posts":              {"/posts", "/posts", "/posts"},
+		"/posts/<unknown:id>": nil,
+		"/posts/<int:id":      nil,
+	}
+	for pat, expected := range cases {
+		if urls := ExampleURLs(pat); !reflect.DeepEqual(urls, expected) {
+			t.Fatalf("%s should have examples %v. Got %v instead", pat,
+				expected, urls)
+		}
+	}
+}
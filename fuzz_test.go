@@ -0,0 +1,144 @@
+package patree
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func FuzzSplitPath(f *testing.F) {
+	for _, pat := range []string{"/foo/<int:bar>-page/about", "/<id>", "<int",
+		"/foo<bar<hoge", "/日本語/<date:d>", "<>", "//"} {
+		f.Add(pat)
+	}
+	f.Fuzz(func(t *testing.T, pat string) {
+		patterns, err := SplitPath(pat)
+		if err != nil {
+			if err != NoClosingBracket {
+				t.Fatalf("%q should be split or NoClosingBracket. Got %v instead",
+					pat, err)
+			}
+			return
+		}
+		if s := strings.Join(patterns, ""); s != pat {
+			t.Fatalf("%q should be split losslessly. Got %q instead", pat, s)
+		}
+		for _, p := range patterns {
+			if p == "" {
+				t.Fatalf("%q should not be split into an empty pattern", pat)
+			}
+		}
+	})
+}
+
+// builtinMatchTypes returns the sorted match types of MatcherMap.
+func builtinMatchTypes() []string {
+	var matchTypes []string
+	for matchType := range MatcherMap {
+		matchTypes = append(matchTypes, matchType)
+	}
+	sort.Strings(matchTypes)
+	return matchTypes
+}
+
+// checkMatch reports the result of Match that doesn't satisfy invariants of
+// Matcher.
+func checkMatch(t *testing.T, name, str string, offset int, matchStr string) {
+	if offset == -1 {
+		if matchStr != "" {
+			t.Fatalf("%s should not return %q without a match of %q", name,
+				matchStr, str)
+		}
+		return
+	}
+	if offset <= 0 || offset > len(str) || matchStr == "" {
+		t.Fatalf("%s should match a part of %q. Got %d, %q instead", name,
+			str, offset, matchStr)
+	}
+}
+
+func FuzzMatchers(f *testing.F) {
+	for _, s := range []string{"2014-01-01", "9E242A66-4EA6-4323-AD5C-66A76F4472FE",
+		"01ARZ3NDEKTSV4RRFFQ69G5FAV", "0ujtsYcgvSTl8PAuAdqWYSMnLOv", "1.0.0-rc.1+b",
+		"1541815603606036480", "dG9rZW4", "123-page", "日本語"} {
+		f.Add(s, "-page")
+	}
+	matchTypes := builtinMatchTypes()
+	f.Fuzz(func(t *testing.T, str, suffix string) {
+		for _, matchType := range matchTypes {
+			m := MatcherMap[matchType]
+			offset, matchStr := m.Match(str)
+			checkMatch(t, matchType, str, offset, matchStr)
+			if _, ok := m.(*uuidMatcher); !ok && offset != -1 &&
+				matchStr != str[:offset] {
+				t.Fatalf("%s should match %q with a prefix. Got %q instead",
+					matchType, str, matchStr)
+			}
+
+			if _, ok := m.(*FixedLengthMatcher); ok || suffix == "" {
+				continue
+			}
+			sm := &SuffixMatcher{suffix, m}
+			offset, matchStr = sm.Match(str)
+			checkMatch(t, matchType+" with suffix "+suffix, str, offset, matchStr)
+			if offset != -1 && !strings.HasSuffix(str[:offset], suffix) {
+				t.Fatalf("%s with suffix %q should match %q with the suffix. "+
+					"Got %q instead", matchType, suffix, str, str[:offset])
+			}
+		}
+	})
+}
+
+// fuzzURL returns an url that should match with the pattern, with the first
+// example params of the match types. ok is false if the pattern has adjacent
+// match patterns, which can't be told apart, or the param value contains the
+// following static pattern, where the suffix matcher stops at the first
+// suffix, e.g. "42" of "<int:id>2".
+func fuzzURL(patterns []string) (urlStr string, ok bool) {
+	var value string
+	for i, p := range patterns {
+		if !isMatchPattern(p) {
+			if value != "" && strings.Index(value+p, p) != len(value) {
+				return "", false
+			}
+			urlStr += p
+			value = ""
+			continue
+		}
+		if i != 0 && isMatchPattern(patterns[i-1]) {
+			return "", false
+		}
+		matchType, _ := splitMatchPattern(p)
+		value = exampleParams[matchType][0]
+		urlStr += value
+	}
+	return urlStr, true
+}
+
+func FuzzRegisterMatch(f *testing.F) {
+	for _, pat := range []string{"/posts/<int:id>", "/foo/<int:bar>-page/about",
+		"/<id>.html", "/files/<hex:h><id>", "/v<semver:v>.tar.gz", "/<date:d>/",
+		"/日本語/<id>"} {
+		f.Add(pat, "GET")
+	}
+	f.Fuzz(func(t *testing.T, pat, method string) {
+		mux := &Route{}
+		if err := mux.handle(pat, method, []HandlerFunc{foobar}, nil); err != nil {
+			return
+		}
+		patterns, _ := SplitPath(pat)
+		urlStr, ok := fuzzURL(patterns)
+		if !ok {
+			return
+		}
+
+		// the url is put into url.URL as it is, since patterns aren't parsed
+		// as urls
+		p := mux.f.(*patternRouter)
+		h, _ := p.match(method, &url.URL{Path: urlStr}, nil)
+		if h == nil || h.pattern != pat {
+			t.Fatalf("%q should match %q", urlStr, pat)
+		}
+	})
+}
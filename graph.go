@@ -0,0 +1,107 @@
+package patree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteDOT writes the entry trees of the route as a Graphviz DOT graph. Each
+// node shows its pattern, match type, weight and methods. Edges are labeled
+// with the order that child entries are evaluated. Roots of chained pattern
+// routers are connected with dashed edges.
+func (r *Route) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("digraph patree {\n\tnode [shape=box];\n")
+	r.walkGraph(func(id int, e *Entry) {
+		label := strings.Join(entryLabel(e), "\\n")
+		fmt.Fprintf(bw, "\tn%d [label=%s];\n", id, dotQuote(label))
+	}, func(from, to, order int) {
+		if order == 0 {
+			fmt.Fprintf(bw, "\tn%d -> n%d [style=dashed];\n", from, to)
+			return
+		}
+		fmt.Fprintf(bw, "\tn%d -> n%d [label=\"%d\"];\n", from, to, order)
+	})
+	bw.WriteString("}\n")
+	return bw.Flush()
+}
+
+// WriteMermaid writes the entry trees of the route as a Mermaid flowchart.
+// Nodes and edges are the same as WriteDOT.
+func (r *Route) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("flowchart TD\n")
+	r.walkGraph(func(id int, e *Entry) {
+		lines := entryLabel(e)
+		for i, line := range lines {
+			lines[i] = mermaidEscape(line)
+		}
+		fmt.Fprintf(bw, "\tn%d[\"%s\"]\n", id, strings.Join(lines, "<br/>"))
+	}, func(from, to, order int) {
+		if order == 0 {
+			fmt.Fprintf(bw, "\tn%d -.-> n%d\n", from, to)
+			return
+		}
+		fmt.Fprintf(bw, "\tn%d -->|%d| n%d\n", from, order, to)
+	})
+	return bw.Flush()
+}
+
+// walkGraph calls node with every entry and its id, and edge with every parent
+// and child ids and the 1-based order of the child. Roots of chained pattern
+// routers are passed to edge with order 0.
+func (r *Route) walkGraph(node func(id int, e *Entry), edge func(from, to, order int)) {
+	var id int
+	var walk func(e *Entry) int
+	walk = func(e *Entry) int {
+		current := id
+		id++
+		node(current, e)
+		for i, child := range e.entries {
+			edge(current, walk(child), i+1)
+		}
+		return current
+	}
+
+	prev := -1
+	for _, root := range r.Entries() {
+		current := walk(root)
+		if prev != -1 {
+			edge(prev, current, 0)
+		}
+		prev = current
+	}
+}
+
+// entryLabel returns the lines that describe the entry.
+func entryLabel(e *Entry) []string {
+	pat := e.pattern
+	if pat == "" {
+		pat = "(root)"
+	}
+	kind := e.matchType
+	if kind == "" {
+		kind = "static"
+	}
+	lines := []string{pat, fmt.Sprintf("%s, weight %d", kind, e.weight)}
+	if methods := e.Methods(); len(methods) != 0 {
+		lines = append(lines, strings.Join(methods, " "))
+	}
+	return lines
+}
+
+// dotQuote returns the DOT quoted string. Escaped sequences such as "\n" are
+// left as they are.
+func dotQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
+
+// mermaidEscape replaces characters that Mermaid interprets in labels with
+// entity codes.
+var mermaidEscape = strings.NewReplacer(
+	`"`, "#quot;",
+	"<", "#lt;",
+	">", "#gt;",
+).Replace
@@ -0,0 +1,63 @@
+package patree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func newGraphTestRoute() *Route {
+	mux := &Route{}
+	mux.Get("/posts/<int:post_id>", foobar)
+	mux.Handle("/posts/2014", foobar)
+	mux.Use(foobar)
+	mux.Post("/about\"", foobar)
+	return mux
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b bytes.Buffer
+	if err := newGraphTestRoute().WriteDOT(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `digraph patree {
+	node [shape=box];
+	n0 [label="(root)\nstatic, weight 1000"];
+	n1 [label="/posts/\nstatic, weight 1007"];
+	n2 [label="2014\nstatic, weight 1004\n*"];
+	n1 -> n2 [label="1"];
+	n3 [label="<int:post_id>\nint, weight 100\nGET HEAD"];
+	n1 -> n3 [label="2"];
+	n0 -> n1 [label="1"];
+	n4 [label="(root)\nstatic, weight 1000"];
+	n5 [label="/about\"\nstatic, weight 1007\nPOST"];
+	n4 -> n5 [label="1"];
+	n0 -> n4 [style=dashed];
+}
+`
+	if b.String() != expected {
+		t.Fatalf("Got\n%s\ninstead of expected\n%s", b.String(), expected)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var b bytes.Buffer
+	if err := newGraphTestRoute().WriteMermaid(&b); err != nil {
+		t.Fatal(err)
+	}
+	expected := `flowchart TD
+	n0["(root)<br/>static, weight 1000"]
+	n1["/posts/<br/>static, weight 1007"]
+	n2["2014<br/>static, weight 1004<br/>*"]
+	n1 -->|1| n2
+	n3["#lt;int:post_id#gt;<br/>int, weight 100<br/>GET HEAD"]
+	n1 -->|2| n3
+	n0 -->|1| n1
+	n4["(root)<br/>static, weight 1000"]
+	n5["/about#quot;<br/>static, weight 1007<br/>POST"]
+	n4 -->|1| n5
+	n0 -.-> n4
+`
+	if b.String() != expected {
+		t.Fatalf("Got\n%s\ninstead of expected\n%s", b.String(), expected)
+	}
+}
@@ -0,0 +1,81 @@
+package patree
+
+// Group registers patterns to a route with middlewares and metadata of the
+// group. Group middlewares run after the pattern matches, so they can read
+// Context.Params. The handlers of a request run in the following order:
+//
+//  1. handlers that are added by Use before the pattern is registered,
+//  2. middlewares of the groups from the outermost one, e.g. auth of
+//     r.With(auth).With(rateLimit),
+//  3. handlers that are passed with the pattern.
+//
+// Handlers that are added by Use after the pattern only run if the request
+// falls through by Context.Next.
+type Group struct {
+	route      *Route
+	middleware []HandlerFunc
+	meta       Meta
+}
+
+// With returns a Group that registers patterns to the route with the
+// middlewares, e.g. r.With(auth).Get("/admin", admin). Middlewares are the
+// handlers of Use.
+func (r *Route) With(f ...interface{}) *Group {
+	return &Group{r, handlerFuncs(f), nil}
+}
+
+// With returns a nested Group that has the middlewares after the ones of the
+// group.
+func (g *Group) With(f ...interface{}) *Group {
+	return &Group{g.route, g.handlers(handlerFuncs(f)), g.meta}
+}
+
+// handlers returns the middlewares of the group followed by f.
+func (g *Group) handlers(f []HandlerFunc) []HandlerFunc {
+	handlers := make([]HandlerFunc, 0, len(g.middleware)+len(f))
+	handlers = append(handlers, g.middleware...)
+	return append(handlers, f...)
+}
+
+// HandleMethod registers handler funcs with the given pattern and method.
+func (g *Group) HandleMethod(pat, method string, f ...interface{}) {
+	if err := g.route.handle(pat, method, g.handlers(handlerFuncs(f)), g.meta); err != nil {
+		panic(err)
+	}
+}
+
+// Handle registers handler funcs with the given pattern.
+func (g *Group) Handle(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "", f...)
+}
+
+// Get registers handlers with the given pattern for GET and HEAD method
+func (g *Group) Get(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "GET", f...)
+	g.HandleMethod(pat, "HEAD", f...)
+}
+
+// Post registers handlers with the given pattern for POST method
+func (g *Group) Post(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "POST", f...)
+}
+
+// Put registers handlers with the given pattern for PUT method
+func (g *Group) Put(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "PUT", f...)
+}
+
+// Patch registers handlers with the given pattern for PATCH method
+func (g *Group) Patch(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "PATCH", f...)
+}
+
+// Delete registers handlers with the given pattern for DELETE method
+func (g *Group) Delete(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "DELETE", f...)
+}
+
+// Options registers handlers with the given pattern for OPTIONS method
+func (g *Group) Options(pat string, f ...interface{}) {
+	g.HandleMethod(pat, "OPTIONS", f...)
+}
@@ -0,0 +1,49 @@
+package patree
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	var calls []string
+	record := func(name string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, c *Context) {
+			calls = append(calls, name+c.Params["id"])
+			c.Next(w, r)
+		}
+	}
+
+	mux := &Route{}
+	mux.Use(record("global"))
+	admin := mux.With(record("auth"))
+	admin.Get("/admin/posts/<int:id>", record("post"))
+	admin.With(record("limit")).Delete("/admin/posts/<int:id>", record("delete"))
+	mux.Get("/posts/<int:id>", record("public"))
+	mux.Use(record("after"))
+
+	cases := []struct {
+		method string
+		urlStr string
+		calls  string
+	}{
+		{"GET", "/admin/posts/1", "global auth1 post1 after1"},
+		{"DELETE", "/admin/posts/2", "global auth2 limit2 delete2 after2"},
+		{"GET", "/posts/3", "global public3 after3"},
+		{"GET", "/users/4", "global after"},
+	}
+	for _, tc := range cases {
+		calls = nil
+		r, _ := http.NewRequest(tc.method, tc.urlStr, nil)
+		mux.ServeHTTP(nil, r)
+		if s := strings.Join(calls, " "); s != tc.calls {
+			t.Fatalf("%s %s should call %s. Got %s instead", tc.method,
+				tc.urlStr, tc.calls, s)
+		}
+	}
+
+	if _, _, ok := mux.Match("GET", "/admin/posts/1"); !ok {
+		t.Fatal("group patterns should be registered to the route")
+	}
+}
@@ -0,0 +1,291 @@
+package patree
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// RouteSpec is a route declaration of a route file.
+type RouteSpec struct {
+	// Name names the route for tools such as code generators. Handler is
+	// used if it's empty.
+	Name string `json:"name"`
+
+	Pattern    string   `json:"pattern"`
+	Methods    []string `json:"methods"`
+	Handler    string   `json:"handler"`
+	Middleware []string `json:"middleware"`
+
+	// Meta is the metadata of the route. See Context.RouteMeta.
+	Meta Meta `json:"meta"`
+
+	// Line is the line number of the declaration in the route file. It is
+	// reported by errors.
+	Line int `json:"-"`
+}
+
+// RouteDecoder decodes route declarations of a route file.
+type RouteDecoder func(data []byte) ([]RouteSpec, error)
+
+// LoadError is the error returned by RouteLoader. It reports where the invalid
+// declaration is.
+type LoadError struct {
+	File    string
+	Line    int
+	Pattern string
+	Err     error
+}
+
+func (e *LoadError) Error() string {
+	var s string
+	if e.File != "" {
+		s = e.File + ":"
+	}
+	if e.Line != 0 {
+		s += fmt.Sprintf("%d:", e.Line)
+	}
+	if s != "" {
+		s += " "
+	}
+	if e.Pattern != "" {
+		s += fmt.Sprintf("pattern %q: ", e.Pattern)
+	}
+	return s + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// RouteLoader registers routes that are declared in route files. A route file
+// declares patterns, methods, handler names and middleware names. Handler and
+// middleware names are resolved through Handlers.
+type RouteLoader struct {
+	// Handlers are HandlerFuncs keyed by handler and middleware names.
+	Handlers map[string]HandlerFunc
+
+	// Decoders are RouteDecoders keyed by file extensions such as ".yaml".
+	// They override the built-in decoders of DecodeRoutes.
+	Decoders map[string]RouteDecoder
+}
+
+// routeDecoders are the built-in RouteDecoders keyed by file extensions.
+var routeDecoders = map[string]RouteDecoder{
+	".json": DecodeJSONRoutes,
+	".yaml": DecodeYAMLRoutes,
+	".yml":  DecodeYAMLRoutes,
+	".toml": DecodeTOMLRoutes,
+}
+
+// LoadFile reads the route file and registers the declared routes to the
+// route. The file is decoded by the decoder of its extension.
+func (l *RouteLoader) LoadFile(r *Route, filename string) error {
+	decode := l.Decoders[strings.ToLower(filepath.Ext(filename))]
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+	specs, err := decodeRoutes(decode, filename, data)
+	if err != nil {
+		return err
+	}
+	return l.Load(r, filename, specs)
+}
+
+// DecodeRoutes decodes the route file by its extension: DecodeJSONRoutes for
+// ".json", DecodeYAMLRoutes for ".yaml" and ".yml", and DecodeTOMLRoutes for
+// ".toml". Errors are LoadErrors of the file.
+func DecodeRoutes(filename string, data []byte) ([]RouteSpec, error) {
+	return decodeRoutes(nil, filename, data)
+}
+
+// decodeRoutes decodes the route file with the decoder, or the built-in
+// decoder of its extension if it's nil.
+func decodeRoutes(decode RouteDecoder, filename string, data []byte) ([]RouteSpec, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if decode == nil {
+		decode = routeDecoders[ext]
+	}
+	if decode == nil {
+		return nil, &LoadError{File: filename,
+			Err: errors.New("no route decoder for extension " + ext)}
+	}
+
+	specs, err := decode(data)
+	if err != nil {
+		if e, ok := err.(*LoadError); ok {
+			e.File = filename
+			return nil, e
+		}
+		return nil, &LoadError{File: filename, Err: err}
+	}
+	return specs, nil
+}
+
+// Load registers the route declarations to the route with HandleMethod, or
+// with Handle if a declaration has no methods. Every declaration is validated
+// before registering any of them, and duplicate registrations are detected by
+// registering them to a copy of the route first, so that nothing is
+// registered if any declaration is invalid. The filename is only used to
+// report errors.
+func (l *RouteLoader) Load(r *Route, filename string, specs []RouteSpec) error {
+	handlers := make([][]HandlerFunc, len(specs))
+	for i, spec := range specs {
+		f, err := l.resolve(r, spec)
+		if err != nil {
+			return &LoadError{filename, spec.Line, spec.Pattern, err}
+		}
+		handlers[i] = f
+	}
+
+	if err := register(r.clone(), filename, specs, handlers); err != nil {
+		return err
+	}
+	return register(r, filename, specs, handlers)
+}
+
+// register registers the route declarations with their handlers.
+func register(r *Route, filename string, specs []RouteSpec, handlers [][]HandlerFunc) error {
+	for i, spec := range specs {
+		methods := spec.Methods
+		if len(methods) == 0 {
+			methods = []string{""}
+		}
+		for _, method := range methods {
+			err := r.handle(spec.Pattern, strings.ToUpper(method), handlers[i],
+				spec.Meta)
+			if err != nil {
+				return &LoadError{filename, spec.Line, spec.Pattern, err}
+			}
+		}
+	}
+	return nil
+}
+
+// resolve validates the declaration and returns its middleware and handler.
+func (l *RouteLoader) resolve(r *Route, spec RouteSpec) ([]HandlerFunc, error) {
+	if err := r.matchers.CheckPattern(spec.Pattern); err != nil {
+		return nil, err
+	}
+
+	var f []HandlerFunc
+	for _, name := range spec.Middleware {
+		h := l.Handlers[name]
+		if h == nil {
+			return nil, errors.New("no such middleware: " + name)
+		}
+		f = append(f, h)
+	}
+
+	if spec.Handler == "" {
+		return nil, errors.New("handler is required")
+	}
+	h := l.Handlers[spec.Handler]
+	if h == nil {
+		return nil, errors.New("no such handler: " + spec.Handler)
+	}
+	return append(f, h), nil
+}
+
+// DecodeJSONRoutes decodes a JSON array of route declarations. For example,
+//
+//	[
+//	  {"pattern": "/posts/<int:id>", "methods": ["GET"], "handler": "post"},
+//	  {"pattern": "/admin", "handler": "admin", "middleware": ["auth"]}
+//	]
+//
+// Line of each RouteSpec is set. Unknown fields are reported as errors.
+func DecodeJSONRoutes(data []byte) ([]RouteSpec, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, jsonLoadError(data, 1, err)
+	}
+	if tok != json.Delim('[') {
+		return nil, &LoadError{Line: lineAt(data, dec.InputOffset()),
+			Err: errors.New("route file must be an array of routes")}
+	}
+
+	var specs []RouteSpec
+	for dec.More() {
+		line := lineAt(data, nextTokenOffset(data, dec.InputOffset()))
+		var spec RouteSpec
+		if err := dec.Decode(&spec); err != nil {
+			return nil, jsonLoadError(data, line, err)
+		}
+		spec.Line = line
+		specs = append(specs, spec)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonLoadError(data, lineAt(data, dec.InputOffset()), err)
+	}
+	return specs, nil
+}
+
+// routeField is a field of a route declaration with its line.
+type routeField struct {
+	key   string
+	value interface{}
+	line  int
+}
+
+// decodeRouteFields decodes the fields of the route declaration at the line
+// as DecodeJSONRoutes does, so that every format has the same fields. Errors
+// are reported at the lines of the fields.
+func decodeRouteFields(fields []routeField, line int) (RouteSpec, error) {
+	spec := RouteSpec{Line: line}
+	for _, f := range fields {
+		b, err := json.Marshal(map[string]interface{}{f.key: f.value})
+		if err == nil {
+			dec := json.NewDecoder(bytes.NewReader(b))
+			dec.DisallowUnknownFields()
+			err = dec.Decode(&spec)
+		}
+		if err != nil {
+			return spec, &LoadError{Line: f.line, Err: err}
+		}
+	}
+	return spec, nil
+}
+
+// jsonLoadError returns a LoadError with the line that the error occurs. The
+// given line is used if the error doesn't tell its offset.
+func jsonLoadError(data []byte, line int, err error) error {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		line = lineAt(data, e.Offset)
+	case *json.UnmarshalTypeError:
+		line = lineAt(data, e.Offset)
+	}
+	return &LoadError{Line: line, Err: err}
+}
+
+// nextTokenOffset skips white spaces and a comma from the offset.
+func nextTokenOffset(data []byte, offset int64) int64 {
+	for offset < int64(len(data)) {
+		switch data[offset] {
+		case ' ', '\t', '\r', '\n', ',':
+			offset++
+		default:
+			return offset
+		}
+	}
+	return offset
+}
+
+// lineAt returns the line number of the offset.
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte{'\n'}) + 1
+}
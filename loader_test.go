@@ -0,0 +1,193 @@
+package patree
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestLoader() *RouteLoader {
+	write := func(s string) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request, c *Context) {
+			io.WriteString(w, s+c.Params["id"])
+			c.Next(w, r)
+		}
+	}
+	return &RouteLoader{Handlers: map[string]HandlerFunc{
+		"auth":  write("auth:"),
+		"post":  write("post"),
+		"posts": write("posts"),
+		"admin": write("admin"),
+	}}
+}
+
+func TestLoadFile(t *testing.T) {
+	files := map[string]string{
+		"routes.json": `[
+  {"pattern": "/posts", "methods": ["GET", "post"], "handler": "posts"},
+  {
+    "pattern": "/posts/<int:id>",
+    "methods": ["GET"],
+    "handler": "post"
+  },
+  {"pattern": "/admin", "handler": "admin", "middleware": ["auth"]}
+]
+`,
+		"routes.yaml": `- pattern: /posts
+  methods: [GET, post]
+  handler: posts
+- pattern: /posts/<int:id>
+  methods:
+    - GET
+  handler: post
+- {pattern: /admin, handler: admin, middleware: [auth]}
+`,
+		"routes.toml": `[[routes]]
+pattern = "/posts"
+methods = ["GET", "post"]
+handler = "posts"
+
+[[routes]]
+pattern = "/posts/<int:id>"
+methods = ["GET"]
+handler = "post"
+
+[[routes]]
+pattern = "/admin"
+handler = "admin"
+middleware = ["auth"]
+`,
+	}
+
+	cases := []struct {
+		method string
+		urlStr string
+		body   string
+	}{
+		{"GET", "/posts", "posts"},
+		{"POST", "/posts", "posts"},
+		{"GET", "/posts/10", "post10"},
+		{"POST", "/posts/10", ""},
+		{"DELETE", "/admin", "auth:admin"},
+	}
+	dir := t.TempDir()
+	for name, data := range files {
+		filename := filepath.Join(dir, name)
+		if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		mux := &Route{}
+		if err := newTestLoader().LoadFile(mux, filename); err != nil {
+			t.Fatal(err)
+		}
+		for _, tc := range cases {
+			r, err := http.NewRequest(tc.method, tc.urlStr, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, r)
+			if body := w.Body.String(); body != tc.body {
+				t.Fatalf("%s: %s %s should respond %q. Got %q instead", name,
+					tc.method, tc.urlStr, tc.body, body)
+			}
+		}
+	}
+}
+
+func TestDecodeJSONRoutes(t *testing.T) {
+	specs, err := DecodeJSONRoutes([]byte(`[
+		{"pattern": "/foo", "handler": "foo"},
+
+		{"pattern": "/bar", "handler": "bar"}, {"pattern": "/baz",
+			"handler": "baz"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range []int{2, 4, 4} {
+		if specs[i].Line != line {
+			t.Fatalf("%s should be at line %d. Got %d instead",
+				specs[i].Pattern, line, specs[i].Line)
+		}
+	}
+}
+
+func TestLoadError(t *testing.T) {
+	cases := []struct {
+		data string
+		line int
+		msg  string
+	}{
+		{`{"pattern": "/foo"}`, 1,
+			"routes.json:1: route file must be an array of routes"},
+		{"[\n{\"pattern\": \"/foo\",\n\"handler\": }]", 3, ""},
+		{"[\n{\"pattern\": \"/foo\", \"handler\": 1}]", 2, ""},
+		{"[\n{\"pattern\": \"/foo\", \"handlers\": \"post\"}]", 2,
+			`routes.json:2: json: unknown field "handlers"`},
+		{"[\n\n{\"pattern\": \"/foo/<int:id\", \"handler\": \"post\"}]", 3,
+			`routes.json:3: pattern "/foo/<int:id": ` + NoClosingBracket.Error()},
+		{"[\n{\"pattern\": \"/foo/<integer:id>\", \"handler\": \"post\"}]", 2,
+			`routes.json:2: pattern "/foo/<integer:id>": no such match type: integer`},
+		{"[\n{\"pattern\": \"/foo\", \"handler\": \"foo\"}]", 2,
+			`routes.json:2: pattern "/foo": no such handler: foo`},
+		{"[\n{\"pattern\": \"/foo\"}]", 2,
+			`routes.json:2: pattern "/foo": handler is required`},
+		{"[{\"pattern\": \"\", \"handler\": \"post\"}]", 1, ""},
+		{"[\n{\"pattern\": \"/foo\", \"handler\": \"post\", \"middleware\": [\"acl\"]}]",
+			2, `routes.json:2: pattern "/foo": no such middleware: acl`},
+		{`[
+			{"pattern": "/foo", "methods": ["GET"], "handler": "post"},
+			{"pattern": "/foo", "methods": ["GET"], "handler": "posts"}
+		]`, 3, `routes.json:3: pattern "/foo": Duplicate Route registration`},
+	}
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "routes.json")
+	for _, tc := range cases {
+		if err := os.WriteFile(filename, []byte(tc.data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		err := newTestLoader().LoadFile(&Route{}, filename)
+		var e *LoadError
+		if !errors.As(err, &e) {
+			t.Fatalf("%s should return LoadError. Got %v instead", tc.data, err)
+		}
+		if e.Line != tc.line {
+			t.Fatalf("%s should return an error at line %d. Got %v instead",
+				tc.data, tc.line, err)
+		}
+		msg := strings.TrimPrefix(err.Error(), dir+string(filepath.Separator))
+		if tc.msg != "" && msg != tc.msg {
+			t.Fatalf("Expected error is %s. Got %s instead", tc.msg, msg)
+		}
+	}
+
+	filename = filepath.Join(dir, "routes.ini")
+	if err := os.WriteFile(filename, []byte("[routes]"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := newTestLoader().LoadFile(&Route{}, filename)
+	if err == nil {
+		t.Fatal("ini file should not be loaded without a decoder")
+	}
+
+	mux := &Route{}
+	mux.Get("/bar", foobar)
+	specs := []RouteSpec{
+		{Pattern: "/foo", Methods: []string{"GET"}, Handler: "post", Line: 1},
+		{Pattern: "/bar", Methods: []string{"GET"}, Handler: "post", Line: 2},
+	}
+	if err := newTestLoader().Load(mux, "", specs); err == nil {
+		t.Fatal("duplicate registration should be an error")
+	}
+	if _, _, ok := mux.Match("GET", "/foo"); ok {
+		t.Fatal("nothing should be registered if a declaration is invalid")
+	}
+}
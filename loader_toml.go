@@ -0,0 +1,415 @@
+package patree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DecodeTOMLRoutes decodes route declarations of a TOML array of tables named
+// "routes". For example,
+//
+//	[[routes]]
+//	pattern = "/posts/<int:id>"
+//	methods = ["GET"]
+//	handler = "post"
+//
+//	[[routes]]
+//	pattern = "/admin"
+//	handler = "admin"
+//	middleware = ["auth"]
+//
+//	[routes.meta]
+//	rate_limit = { rate = 10, burst = 20 }
+//
+// Fields are the ones of DecodeJSONRoutes. Strings, numbers, booleans,
+// arrays, inline tables, dotted keys and sub-tables of routes are supported.
+// Multi-line strings and dates aren't. Line of each RouteSpec is set, and
+// errors report their lines.
+func DecodeTOMLRoutes(data []byte) ([]RouteSpec, error) {
+	var routes []*tomlRoute
+	var current *tomlRoute
+	var table map[string]interface{} // sub-table of the current route
+
+	p := &tomlParser{s: string(data), line: 1}
+	for {
+		p.skip(true)
+		if p.done() {
+			break
+		}
+
+		line := p.line
+		if strings.HasPrefix(p.s[p.i:], "[[") {
+			p.i += 2
+			keys, err := p.keys("]]")
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) != 1 || keys[0] != "routes" {
+				return nil, p.errorf("unknown array of tables %s",
+					strings.Join(keys, "."))
+			}
+			current = &tomlRoute{line: line}
+			routes = append(routes, current)
+			table = nil
+		} else if p.s[p.i] == '[' {
+			p.i++
+			keys, err := p.keys("]")
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) < 2 || keys[0] != "routes" || current == nil {
+				return nil, p.errorf("unknown table %s", strings.Join(keys, "."))
+			}
+			// the first key is a field of the route
+			var ok bool
+			table = nil
+			for _, f := range current.fields {
+				if f.key == keys[1] {
+					table, ok = f.value.(map[string]interface{})
+					if !ok {
+						return nil, p.errorf("key %s is already defined", keys[1])
+					}
+				}
+			}
+			if table == nil {
+				table = make(map[string]interface{})
+				current.fields = append(current.fields,
+					routeField{keys[1], table, line})
+			}
+			if table, err = tomlTable(table, keys[2:]); err != nil {
+				return nil, p.errorf("%s", err)
+			}
+		} else {
+			keys, err := p.keys("=")
+			if err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			if current == nil {
+				return nil, &LoadError{Line: line,
+					Err: errors.New("toml: keys must be in [[routes]] tables")}
+			}
+			if table != nil {
+				err = tomlSet(table, keys, v)
+			} else {
+				err = current.set(keys, v, line)
+			}
+			if err != nil {
+				return nil, &LoadError{Line: line, Err: errors.New("toml: " + err.Error())}
+			}
+		}
+		if err := p.endOfLine(); err != nil {
+			return nil, err
+		}
+	}
+
+	specs := make([]RouteSpec, 0, len(routes))
+	for _, r := range routes {
+		spec, err := decodeRouteFields(r.fields, r.line)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// tomlRoute is a table of [[routes]].
+type tomlRoute struct {
+	line   int
+	fields []routeField
+}
+
+// set sets the value of the dotted keys to the route. The first key is a
+// field of the route.
+func (r *tomlRoute) set(keys []string, v interface{}, line int) error {
+	for _, f := range r.fields {
+		if f.key != keys[0] {
+			continue
+		}
+		if m, ok := f.value.(map[string]interface{}); ok && len(keys) > 1 {
+			return tomlSet(m, keys[1:], v)
+		}
+		return errors.New("key " + keys[0] + " is already defined")
+	}
+	if len(keys) > 1 {
+		m := make(map[string]interface{})
+		r.fields = append(r.fields, routeField{keys[0], m, line})
+		return tomlSet(m, keys[1:], v)
+	}
+	r.fields = append(r.fields, routeField{keys[0], v, line})
+	return nil
+}
+
+// tomlTable returns the table of the keys in the table, which is created if
+// it doesn't exist.
+func tomlTable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, k := range keys {
+		switch v := table[k].(type) {
+		case nil:
+			m := make(map[string]interface{})
+			table[k] = m
+			table = m
+		case map[string]interface{}:
+			table = v
+		default:
+			return nil, errors.New("key " + k + " is already defined")
+		}
+	}
+	return table, nil
+}
+
+// tomlSet sets the value of the dotted keys to the table.
+func tomlSet(table map[string]interface{}, keys []string, v interface{}) error {
+	table, err := tomlTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	k := keys[len(keys)-1]
+	if _, ok := table[k]; ok {
+		return errors.New("key " + k + " is already defined")
+	}
+	table[k] = v
+	return nil
+}
+
+// tomlParser scans a TOML document.
+type tomlParser struct {
+	s    string
+	i    int
+	line int
+}
+
+func (p *tomlParser) done() bool {
+	return p.i >= len(p.s)
+}
+
+func (p *tomlParser) errorf(format string, a ...interface{}) error {
+	return &LoadError{Line: p.line, Err: fmt.Errorf("toml: "+format, a...)}
+}
+
+// skip skips white spaces and comments, and newlines as well if newlines is
+// set.
+func (p *tomlParser) skip(newlines bool) {
+	for !p.done() {
+		switch c := p.s[p.i]; {
+		case c == ' ' || c == '\t' || c == '\r':
+			p.i++
+		case c == '\n' && newlines:
+			p.i++
+			p.line++
+		case c == '#':
+			for !p.done() && p.s[p.i] != '\n' {
+				p.i++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// endOfLine scans the end of a key/value pair or a table header.
+func (p *tomlParser) endOfLine() error {
+	p.skip(false)
+	if p.done() {
+		return nil
+	}
+	if p.s[p.i] != '\n' {
+		return p.errorf("unexpected %q", p.rest())
+	}
+	return nil
+}
+
+// rest returns the rest of the current line for errors.
+func (p *tomlParser) rest() string {
+	s := p.s[p.i:]
+	if i := strings.IndexByte(s, '\n'); i != -1 {
+		s = s[:i]
+	}
+	return strings.TrimSpace(s)
+}
+
+// keys scans dotted keys followed by the terminator.
+func (p *tomlParser) keys(terminator string) ([]string, error) {
+	var keys []string
+	for {
+		p.skip(false)
+		if p.done() {
+			return nil, p.errorf("expected a key")
+		}
+		var key string
+		switch c := p.s[p.i]; {
+		case c == '"' || c == '\'':
+			s, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.i
+			for !p.done() && isTOMLBareKeyChar(p.s[p.i]) {
+				p.i++
+			}
+			if p.i == start {
+				return nil, p.errorf("expected a key. Got %q", p.rest())
+			}
+			key = p.s[start:p.i]
+		}
+		keys = append(keys, key)
+
+		p.skip(false)
+		if strings.HasPrefix(p.s[p.i:], terminator) {
+			p.i += len(terminator)
+			return keys, nil
+		}
+		if p.done() || p.s[p.i] != '.' {
+			return nil, p.errorf("expected %q. Got %q", terminator, p.rest())
+		}
+		p.i++
+	}
+}
+
+func isTOMLBareKeyChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' ||
+		'0' <= c && c <= '9' || c == '_' || c == '-'
+}
+
+// value scans a value.
+func (p *tomlParser) value() (interface{}, error) {
+	p.skip(false)
+	if p.done() {
+		return nil, p.errorf("expected a value")
+	}
+	switch c := p.s[p.i]; {
+	case c == '"' || c == '\'':
+		return p.str()
+	case c == '[':
+		return p.array()
+	case c == '{':
+		return p.inlineTable()
+	}
+
+	start := p.i
+	for !p.done() && strings.IndexByte(" \t\r\n,]}#", p.s[p.i]) == -1 {
+		p.i++
+	}
+	tok := p.s[start:p.i]
+	switch tok {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+	if n, err := strconv.ParseInt(tok, 0, 64); err == nil {
+		return n, nil
+	}
+	if n, err := strconv.ParseFloat(strings.ReplaceAll(tok, "_", ""), 64); err == nil {
+		return n, nil
+	}
+	if strings.ContainsAny(tok, "-:") && tok != "" && '0' <= tok[0] && tok[0] <= '9' {
+		return nil, p.errorf("dates aren't supported: %s", tok)
+	}
+	return nil, p.errorf("invalid value %q", tok)
+}
+
+// str scans a basic or literal string on a line.
+func (p *tomlParser) str() (string, error) {
+	quote := p.s[p.i]
+	if strings.HasPrefix(p.s[p.i:], strings.Repeat(string(quote), 3)) {
+		return "", p.errorf("multi-line strings aren't supported")
+	}
+	start := p.i
+	for p.i++; !p.done() && p.s[p.i] != '\n'; p.i++ {
+		c := p.s[p.i]
+		if quote == '"' && c == '\\' {
+			p.i++
+			continue
+		}
+		if c != quote {
+			continue
+		}
+		p.i++
+		if quote == '\'' {
+			return p.s[start+1 : p.i-1], nil
+		}
+		s, err := strconv.Unquote(p.s[start:p.i])
+		if err != nil {
+			return "", p.errorf("invalid string %s", p.s[start:p.i])
+		}
+		return s, nil
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// array scans an array, which may span lines.
+func (p *tomlParser) array() ([]interface{}, error) {
+	a := []interface{}{}
+	line := p.line
+	p.i++
+	for {
+		p.skip(true)
+		if p.done() {
+			return nil, &LoadError{Line: line,
+				Err: errors.New("toml: unterminated array")}
+		}
+		if p.s[p.i] == ']' {
+			p.i++
+			return a, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		a = append(a, v)
+
+		p.skip(true)
+		if !p.done() && p.s[p.i] == ',' {
+			p.i++
+		} else if !p.done() && p.s[p.i] != ']' {
+			return nil, p.errorf("expected ',' or ']'. Got %q", p.rest())
+		}
+	}
+}
+
+// inlineTable scans an inline table on a line.
+func (p *tomlParser) inlineTable() (map[string]interface{}, error) {
+	table := make(map[string]interface{})
+	p.i++
+	p.skip(false)
+	if !p.done() && p.s[p.i] == '}' {
+		p.i++
+		return table, nil
+	}
+	for {
+		keys, err := p.keys("=")
+		if err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if err := tomlSet(table, keys, v); err != nil {
+			return nil, p.errorf("%s", err)
+		}
+
+		p.skip(false)
+		if p.done() {
+			return nil, p.errorf("unterminated inline table")
+		}
+		switch p.s[p.i] {
+		case ',':
+			p.i++
+		case '}':
+			p.i++
+			return table, nil
+		default:
+			return nil, p.errorf("expected ',' or '}'. Got %q", p.rest())
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package patree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeTOMLRoutes(t *testing.T) {
+	specs, err := DecodeTOMLRoutes([]byte(`# routes of the blog
+[[routes]]
+name = "post"
+pattern = "/posts/<int:id>" # comment
+methods = ["GET", 'DELETE']
+handler = "post"
+
+[[routes]]
+pattern = '/posts/<slug>#it\s'
+handler = "posts"
+middleware = [
+  "auth", # comment
+  "acl",
+]
+meta.public = true
+
+[routes.meta.rate_limit]
+rate = 1.5
+burst = 1_0
+params = ["slug"]
+
+[routes.meta.tags]
+a = { "b" = 0x10, c.d = false }
+
+[[routes]]
+"pattern" = "/admin"
+handler = "admin"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []RouteSpec{
+		{Name: "post", Pattern: "/posts/<int:id>",
+			Methods: []string{"GET", "DELETE"}, Handler: "post", Line: 2},
+		{Pattern: `/posts/<slug>#it\s`, Handler: "posts",
+			Middleware: []string{"auth", "acl"}, Meta: Meta{
+				"public": true,
+				"rate_limit": map[string]interface{}{"rate": 1.5,
+					"burst": 10.0, "params": []interface{}{"slug"}},
+				"tags": map[string]interface{}{"a": map[string]interface{}{
+					"b": 16.0, "c": map[string]interface{}{"d": false}}},
+			}, Line: 8},
+		{Pattern: "/admin", Handler: "admin", Line: 25},
+	}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Fatalf("specs should be %v. Got %v instead", expected, specs)
+	}
+
+	if specs, err := DecodeTOMLRoutes([]byte("# no routes\n")); err != nil || len(specs) != 0 {
+		t.Fatalf("empty file should have no routes. Got %v %v instead", specs, err)
+	}
+}
+
+func TestDecodeTOMLRoutesError(t *testing.T) {
+	cases := []struct {
+		data string
+		line int
+		msg  string
+	}{
+		{"pattern = \"/foo\"\n", 1, "1: toml: keys must be in [[routes]] tables"},
+		{"[[route]]\n", 1, "1: toml: unknown array of tables route"},
+		{"[[routes]]\n[meta]\n", 2, "2: toml: unknown table meta"},
+		{"[[routes]]\npattern = \"/foo\"\nhandlers = \"foo\"\n", 3,
+			`3: json: unknown field "handlers"`},
+		{"[[routes]]\npattern = \"/foo\"\n\nhandler = 1\n", 4, ""},
+		{"[[routes]]\nhandler = \"foo\"\nhandler = \"bar\"\n", 3,
+			"3: toml: key handler is already defined"},
+		{"[[routes]]\nmethods = [\"GET\",\n\"POST\"\n", 2, "2: toml: unterminated array"},
+		{"[[routes]]\npattern = \"/foo\" handler = \"foo\"\n", 2,
+			`2: toml: unexpected "handler = \"foo\""`},
+		{"[[routes]]\npattern = \"/foo\n", 2, "2: toml: unterminated string"},
+		{"[[routes]]\nmeta.since = 2014-01-01\n", 2,
+			"2: toml: dates aren't supported: 2014-01-01"},
+		{"[[routes]]\nhandler = \"\"\"foo\"\"\"\n", 2,
+			"2: toml: multi-line strings aren't supported"},
+	}
+	for _, tc := range cases {
+		_, err := DecodeTOMLRoutes([]byte(tc.data))
+		var e *LoadError
+		if !errors.As(err, &e) {
+			t.Fatalf("%q should return LoadError. Got %v instead", tc.data, err)
+		}
+		if e.Line != tc.line {
+			t.Fatalf("%q should return an error at line %d. Got %v instead",
+				tc.data, tc.line, err)
+		}
+		if tc.msg != "" && err.Error() != tc.msg {
+			t.Fatalf("Expected error is %s. Got %s instead", tc.msg, err)
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package patree
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DecodeYAMLRoutes decodes a YAML sequence of route declarations. For example,
+//
+//	# routes.yaml
+//	- pattern: /posts/<int:id>
+//	  methods: [GET]
+//	  handler: post
+//	- pattern: /admin
+//	  handler: admin
+//	  middleware:
+//	    - auth
+//
+// Fields are the ones of DecodeJSONRoutes. Block mappings and sequences,
+// flow mappings and sequences on a line, plain and quoted scalars and
+// comments are supported. Block scalars, anchors, tags and multiple documents
+// aren't. Line of each RouteSpec is set, and errors report their lines.
+func DecodeYAMLRoutes(data []byte) ([]RouteSpec, error) {
+	p, err := newYAMLParser(data)
+	if err != nil {
+		return nil, err
+	}
+	if p.done() {
+		return nil, nil
+	}
+
+	line := p.lines[0].num
+	v, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, p.errorf("unexpected indentation")
+	}
+	items, ok := v.(yamlSequence)
+	if !ok {
+		return nil, &LoadError{Line: line,
+			Err: errors.New("route file must be a sequence of routes")}
+	}
+
+	specs := make([]RouteSpec, 0, len(items))
+	for _, item := range items {
+		m, ok := item.value.(yamlMapping)
+		if !ok {
+			return nil, &LoadError{Line: item.line,
+				Err: errors.New("route must be a mapping")}
+		}
+		fields := make([]routeField, len(m))
+		for i, f := range m {
+			fields[i] = routeField{f.key, plainYAMLValue(f.value), f.line}
+		}
+		spec, err := decodeRouteFields(fields, item.line)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// yamlLine is a line of a YAML document without its comment.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+}
+
+// yamlMapping is a YAML mapping with the lines of its keys.
+type yamlMapping []routeField
+
+// yamlSequence is a YAML sequence with the lines of its items.
+type yamlSequence []yamlItem
+
+type yamlItem struct {
+	value interface{}
+	line  int
+}
+
+// plainYAMLValue returns the value with maps and slices in place of mappings
+// and sequences.
+func plainYAMLValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case yamlMapping:
+		m := make(map[string]interface{}, len(v))
+		for _, f := range v {
+			m[f.key] = plainYAMLValue(f.value)
+		}
+		return m
+	case yamlSequence:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = plainYAMLValue(item.value)
+		}
+		return s
+	}
+	return v
+}
+
+// yamlParser parses the block structure of a YAML document line by line.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+// newYAMLParser splits the data into lines. Blank lines, comments and the
+// document start marker are skipped.
+func newYAMLParser(data []byte) (*yamlParser, error) {
+	p := &yamlParser{}
+	for i, s := range strings.Split(string(data), "\n") {
+		s = strings.TrimRight(stripYAMLComment(strings.TrimSuffix(s, "\r")), " \t")
+		text := strings.TrimLeft(s, " ")
+		if text == "" || (len(p.lines) == 0 && text == "---") {
+			continue
+		}
+		if text[0] == '\t' {
+			return nil, &LoadError{Line: i + 1,
+				Err: errors.New("yaml: tabs can't indent")}
+		}
+		p.lines = append(p.lines, yamlLine{i + 1, len(s) - len(text), text})
+	}
+	return p, nil
+}
+
+// stripYAMLComment removes the comment of the line. A comment starts with
+// '#' that follows a white space, or that starts the line, outside quotes.
+func stripYAMLComment(s string) string {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '#' && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return s[:i]
+		case (c == '"' || c == '\'') && (i == 0 || strings.IndexByte(" \t[{,:", s[i-1]) != -1):
+			quote = c
+		}
+	}
+	return s
+}
+
+func (p *yamlParser) done() bool {
+	return p.pos == len(p.lines)
+}
+
+func (p *yamlParser) errorf(format string, a ...interface{}) error {
+	line := 0
+	if !p.done() {
+		line = p.lines[p.pos].num
+	} else if len(p.lines) != 0 {
+		line = p.lines[len(p.lines)-1].num
+	}
+	return &LoadError{Line: line, Err: fmt.Errorf("yaml: "+format, a...)}
+}
+
+// parseBlock parses the block node of the current line.
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	l := p.lines[p.pos]
+	if isYAMLSequenceItem(l.text) {
+		return p.parseSequence(indent)
+	}
+	if _, _, ok := splitYAMLKey(l.text); ok {
+		return p.parseMapping(indent)
+	}
+	p.pos++
+	return parseYAMLFlow(l.text, l.num)
+}
+
+// parseChild parses the block node of the lines indented more than indent.
+// It returns nil if there is none.
+func (p *yamlParser) parseChild(indent int) (interface{}, error) {
+	if p.done() || p.lines[p.pos].indent <= indent {
+		return nil, nil
+	}
+	return p.parseBlock(p.lines[p.pos].indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (yamlSequence, error) {
+	var seq yamlSequence
+	for !p.done() {
+		l := p.lines[p.pos]
+		if l.indent != indent || !isYAMLSequenceItem(l.text) {
+			break
+		}
+
+		var v interface{}
+		var err error
+		rest := strings.TrimLeft(l.text[1:], " ")
+		switch {
+		case rest == "":
+			p.pos++
+			v, err = p.parseChild(indent)
+		case isYAMLSequenceItem(rest) || isYAMLKey(rest):
+			// the item is a block node that starts on the line
+			offset := indent + len(l.text) - len(rest)
+			p.lines[p.pos] = yamlLine{l.num, offset, rest}
+			v, err = p.parseBlock(offset)
+		default:
+			p.pos++
+			v, err = parseYAMLFlow(rest, l.num)
+		}
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, yamlItem{v, l.num})
+		if !p.done() && p.lines[p.pos].indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+	}
+	return seq, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (yamlMapping, error) {
+	var m yamlMapping
+	for !p.done() {
+		l := p.lines[p.pos]
+		if l.indent != indent || isYAMLSequenceItem(l.text) {
+			break
+		}
+		key, rest, ok := splitYAMLKey(l.text)
+		if !ok {
+			return nil, p.errorf("expected a key of the mapping")
+		}
+		for _, f := range m {
+			if f.key == key {
+				return nil, p.errorf("duplicate key %s", key)
+			}
+		}
+
+		var v interface{}
+		var err error
+		p.pos++
+		switch {
+		case rest != "":
+			v, err = parseYAMLFlow(rest, l.num)
+		case !p.done() && p.lines[p.pos].indent == indent &&
+			isYAMLSequenceItem(p.lines[p.pos].text):
+			// a sequence may be indented as much as its key
+			v, err = p.parseSequence(indent)
+		default:
+			v, err = p.parseChild(indent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, routeField{key, v, l.num})
+		if !p.done() && p.lines[p.pos].indent > indent {
+			return nil, p.errorf("unexpected indentation")
+		}
+	}
+	return m, nil
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+func isYAMLKey(text string) bool {
+	_, _, ok := splitYAMLKey(text)
+	return ok
+}
+
+// splitYAMLKey splits the line of a block mapping into the key and the rest.
+// The key ends with ':' that is followed by a space or the end of the line.
+func splitYAMLKey(text string) (key, rest string, ok bool) {
+	if text == "" || strings.IndexByte("[{", text[0]) != -1 {
+		return "", "", false
+	}
+	if text[0] == '"' || text[0] == '\'' {
+		f := &yamlFlow{s: text}
+		k, err := f.quoted()
+		if err != nil {
+			return "", "", false
+		}
+		after := strings.TrimLeft(text[f.i:], " ")
+		if after != ":" && !strings.HasPrefix(after, ": ") {
+			return "", "", false
+		}
+		return k, strings.TrimSpace(after[1:]), true
+	}
+	for i := 0; i < len(text); i++ {
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+	return "", "", false
+}
+
+// parseYAMLFlow parses the scalar, flow sequence or flow mapping of a line.
+func parseYAMLFlow(s string, line int) (interface{}, error) {
+	f := &yamlFlow{s: s, line: line}
+	v, err := f.value(false)
+	if err == nil {
+		f.skipSpaces()
+		if f.i != len(s) {
+			err = fmt.Errorf("unexpected %q", s[f.i:])
+		}
+	}
+	if err != nil {
+		return nil, &LoadError{Line: line, Err: errors.New("yaml: " + err.Error())}
+	}
+	return v, nil
+}
+
+// yamlFlow scans flow nodes of a line.
+type yamlFlow struct {
+	s    string
+	i    int
+	line int
+}
+
+func (f *yamlFlow) skipSpaces() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+// value scans a node. Plain scalars in flow collections end with ',' and
+// closing brackets.
+func (f *yamlFlow) value(inFlow bool) (interface{}, error) {
+	f.skipSpaces()
+	if f.i == len(f.s) {
+		return nil, nil
+	}
+	switch c := f.s[f.i]; {
+	case c == '[':
+		return f.sequence()
+	case c == '{':
+		return f.mapping()
+	case c == '"' || c == '\'':
+		return f.quoted()
+	case strings.IndexByte("|>&*!%@`", c) != -1:
+		return nil, fmt.Errorf("%q isn't supported", c)
+	}
+
+	start := f.i
+	stop := ""
+	if inFlow {
+		stop = ",]}"
+	}
+	for f.i < len(f.s) && strings.IndexByte(stop, f.s[f.i]) == -1 {
+		if inFlow && f.s[f.i] == ':' && (f.i+1 == len(f.s) || f.s[f.i+1] == ' ') {
+			break
+		}
+		f.i++
+	}
+	return resolveYAMLScalar(strings.TrimSpace(f.s[start:f.i])), nil
+}
+
+// quoted scans a single or double-quoted scalar.
+func (f *yamlFlow) quoted() (string, error) {
+	quote := f.s[f.i]
+	start := f.i
+	for f.i++; f.i < len(f.s); f.i++ {
+		c := f.s[f.i]
+		if quote == '"' && c == '\\' {
+			f.i++
+			continue
+		}
+		if c != quote {
+			continue
+		}
+		if quote == '\'' && f.i+1 < len(f.s) && f.s[f.i+1] == '\'' {
+			f.i++
+			continue
+		}
+		f.i++
+		if quote == '\'' {
+			return strings.ReplaceAll(f.s[start+1:f.i-1], "''", "'"), nil
+		}
+		s, err := strconv.Unquote(f.s[start:f.i])
+		if err != nil {
+			return "", errors.New("invalid double-quoted scalar " + f.s[start:f.i])
+		}
+		return s, nil
+	}
+	return "", errors.New("unterminated quoted scalar")
+}
+
+func (f *yamlFlow) sequence() (yamlSequence, error) {
+	seq := yamlSequence{}
+	f.i++
+	for {
+		f.skipSpaces()
+		if f.i == len(f.s) {
+			return nil, errors.New("unterminated flow sequence")
+		}
+		if f.s[f.i] == ']' {
+			f.i++
+			return seq, nil
+		}
+		v, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, yamlItem{v, f.line})
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (yamlMapping, error) {
+	m := yamlMapping{}
+	f.i++
+	for {
+		f.skipSpaces()
+		if f.i == len(f.s) {
+			return nil, errors.New("unterminated flow mapping")
+		}
+		if f.s[f.i] == '}' {
+			f.i++
+			return m, nil
+		}
+		k, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			key = fmt.Sprint(k)
+		}
+		for _, field := range m {
+			if field.key == key {
+				return nil, errors.New("duplicate key " + key)
+			}
+		}
+
+		var v interface{}
+		f.skipSpaces()
+		if f.i < len(f.s) && f.s[f.i] == ':' {
+			f.i++
+			if v, err = f.value(true); err != nil {
+				return nil, err
+			}
+		}
+		m = append(m, routeField{key, v, f.line})
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator scans ',' or leaves the closing bracket for the caller.
+func (f *yamlFlow) separator(closing byte) error {
+	f.skipSpaces()
+	switch {
+	case f.i == len(f.s):
+		return nil
+	case f.s[f.i] == ',':
+		f.i++
+		return nil
+	case f.s[f.i] == closing:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q", f.s[f.i:])
+}
+
+// resolveYAMLScalar returns the value of the plain scalar, which is null, a
+// boolean, a number or a string.
+func resolveYAMLScalar(s string) interface{} {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	if isYAMLFloat(s) {
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+	return s
+}
+
+// isYAMLFloat see if the scalar consists of the characters of decimal
+// floats, e.g. "1.5" and "1e3", but not "1.0.0", which ParseFloat rejects.
+func isYAMLFloat(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte("0123456789+-.eE", s[i]) == -1 {
+			return false
+		}
+	}
+	return true
+}
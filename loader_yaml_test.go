@@ -0,0 +1,89 @@
+package patree
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeYAMLRoutes(t *testing.T) {
+	specs, err := DecodeYAMLRoutes([]byte(`---
+# routes of the blog
+- name: post
+  pattern: /posts/<int:id>   # comment
+  methods: [GET, "DELETE"]
+  handler: post
+
+-
+  pattern: '/posts/<slug>#it''s'
+  handler: "posts"
+  middleware:
+  - auth
+  - 'acl'
+  meta:
+    public: true
+    rate_limit:
+      rate: 1.5
+      burst: 10
+      params: [slug]
+    tags: {a: 1, b: ~}
+- {pattern: /admin, handler: admin}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []RouteSpec{
+		{Name: "post", Pattern: "/posts/<int:id>",
+			Methods: []string{"GET", "DELETE"}, Handler: "post", Line: 3},
+		{Pattern: "/posts/<slug>#it's", Handler: "posts",
+			Middleware: []string{"auth", "acl"}, Meta: Meta{
+				"public": true,
+				"rate_limit": map[string]interface{}{"rate": 1.5,
+					"burst": 10.0, "params": []interface{}{"slug"}},
+				"tags": map[string]interface{}{"a": 1.0, "b": nil},
+			}, Line: 8},
+		{Pattern: "/admin", Handler: "admin", Line: 21},
+	}
+	if !reflect.DeepEqual(specs, expected) {
+		t.Fatalf("specs should be %v. Got %v instead", expected, specs)
+	}
+
+	if specs, err := DecodeYAMLRoutes([]byte("# no routes\n")); err != nil || len(specs) != 0 {
+		t.Fatalf("empty file should have no routes. Got %v %v instead", specs, err)
+	}
+}
+
+func TestDecodeYAMLRoutesError(t *testing.T) {
+	cases := []struct {
+		data string
+		line int
+		msg  string
+	}{
+		{"pattern: /foo\n", 1, "1: route file must be a sequence of routes"},
+		{"- /foo\n", 1, "1: route must be a mapping"},
+		{"- pattern: /foo\n  handlers: foo\n", 2, `2: json: unknown field "handlers"`},
+		{"- pattern: /foo\n\n  handler: [foo]\n", 3, ""},
+		{"- pattern: /foo\n  handler: foo\n  handler: bar\n", 3,
+			"3: yaml: duplicate key handler"},
+		{"- pattern: /foo\n    handler: foo\n", 2, "2: yaml: unexpected indentation"},
+		{"- pattern: /foo\n  methods: [GET\n", 2, "2: yaml: unterminated flow sequence"},
+		{"- pattern: \"/foo\n", 1, "1: yaml: unterminated quoted scalar"},
+		{"- pattern: /foo\n  handler: |\n    foo\n", 2, "2: yaml: '|' isn't supported"},
+		{"- pattern: /foo\n\thandler: foo\n", 2, "2: yaml: tabs can't indent"},
+	}
+	for _, tc := range cases {
+		_, err := DecodeYAMLRoutes([]byte(tc.data))
+		var e *LoadError
+		if !errors.As(err, &e) {
+			t.Fatalf("%q should return LoadError. Got %v instead", tc.data, err)
+		}
+		if e.Line != tc.line {
+			t.Fatalf("%q should return an error at line %d. Got %v instead",
+				tc.data, tc.line, err)
+		}
+		if tc.msg != "" && err.Error() != tc.msg {
+			t.Fatalf("Expected error is %s. Got %s instead", tc.msg, err)
+		}
+	}
+}
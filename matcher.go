@@ -1,11 +1,28 @@
 package patree
 
+import (
+	"strconv"
+	"strings"
+)
+
 var (
-	IntMatcher     = RuneMatcherFunc(isDigit)
-	HexMatcher     = RuneMatcherFunc(isHex)
-	DefaultMatcher = RuneMatcherFunc(isNotSlash)
-	UUIDMatcher    = &FixedLengthMatcher{36, isHex, hasUUIDPrefix}
-	DateMatcher    = &FixedLengthMatcher{10, isDigit, hasDatePrefix} // YYYY-MM-DD
+	IntMatcher       = RuneMatcherFunc(isDigit)
+	HexMatcher       = RuneMatcherFunc(isHex)
+	DefaultMatcher   = RuneMatcherFunc(isNotSlash)
+	UUIDMatcher      = &FixedLengthMatcher{36, isHex, hasUUIDPrefix}
+	DateMatcher      = &FixedLengthMatcher{10, isDigit, hasDatePrefix} // YYYY-MM-DD
+	ULIDMatcher      = &FixedLengthMatcher{26, isCrockford, hasULIDPrefix}
+	KSUIDMatcher     = &FixedLengthMatcher{27, isBase62, hasKSUIDPrefix}
+	ObjectIDMatcher  = &FixedLengthMatcher{24, isHex, hasObjectIDPrefix}
+	SnowflakeMatcher = &ValidatingMatcher{isDigit, isSnowflake}
+	Base64URLMatcher = &ValidatingMatcher{isBase64URL, isBase64URLToken}
+	SemverMatcher    = &ValidatingMatcher{isSemverRune, isSemver}
+	PathMatcher      = RuneMatcherFunc(isAnyRune)
+
+	UUID4Matcher       = NewUUIDMatcher(UUIDOptions{Version: 4})
+	UUID7Matcher       = NewUUIDMatcher(UUIDOptions{Version: 7})
+	LowerUUIDMatcher   = NewUUIDMatcher(UUIDOptions{Lowercase: true})
+	CompactUUIDMatcher = NewUUIDMatcher(UUIDOptions{Compact: true})
 )
 
 func isDigit(r rune) bool {
@@ -20,6 +37,10 @@ func isNotSlash(r rune) bool {
 	return r != '/'
 }
 
+func isAnyRune(r rune) bool {
+	return true
+}
+
 // UUID's 8, 13, 23, 18 is '-'
 // e.g. 9E242A66-4EA6-4323-AD5C-66A76F4472FE
 func hasUUIDPrefix(s string) bool {
@@ -52,6 +73,149 @@ func hasUUIDPrefix(s string) bool {
 	return false
 }
 
+// Crockford's Base32 excludes I, L, O and U. It is case insensitive.
+func isCrockford(r rune) bool {
+	if 'a' <= r && r <= 'z' {
+		r -= 'a' - 'A'
+	}
+	switch r {
+	case 'I', 'L', 'O', 'U':
+		return false
+	}
+	return isDigit(r) || ('A' <= r && r <= 'Z')
+}
+
+func isBase62(r rune) bool {
+	return isDigit(r) || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z')
+}
+
+func isBase64URL(r rune) bool {
+	return isBase62(r) || r == '-' || r == '_'
+}
+
+// identifier characters and separators of semantic versions
+func isSemverRune(r rune) bool {
+	return isBase62(r) || r == '-' || r == '.' || r == '+'
+}
+
+// hasPrefixFunc see if the first n bytes of s are single byte runes that
+// match f.
+func hasPrefixFunc(s string, n int, f RuneMatcherFunc) bool {
+	if len(s) < n {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		if s[i] >= 0x80 || !f(rune(s[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+// ULID is 26 characters of Crockford's Base32. The first character is at most
+// '7' since ULID is 128 bits.
+// e.g. 01ARZ3NDEKTSV4RRFFQ69G5FAV
+func hasULIDPrefix(s string) bool {
+	return hasPrefixFunc(s, 26, isCrockford) && s[0] <= '7'
+}
+
+// KSUID is 27 characters of Base62. The maximum value is
+// "aWgEPTl1tmebfsQzFP4bxwgy80V" since KSUID is 160 bits.
+// e.g. 0ujtsYcgvSTl8PAuAdqWYSMnLOv
+func hasKSUIDPrefix(s string) bool {
+	return hasPrefixFunc(s, 27, isBase62) &&
+		s[:27] <= "aWgEPTl1tmebfsQzFP4bxwgy80V"
+}
+
+// MongoDB ObjectID is 24 hex characters.
+// e.g. 507f1f77bcf86cd799439011
+func hasObjectIDPrefix(s string) bool {
+	return hasPrefixFunc(s, 24, isHex)
+}
+
+// Snowflake ID is a positive 63 bit integer without leading zeros.
+// e.g. 1541815603606036480
+func isSnowflake(s string) bool {
+	if s[0] == '0' {
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// Base64URL token is an unpadded base64url string. A length of 4n+1 can't be
+// decoded.
+// e.g. dGhpcyBpcyBhIHRva2Vu
+func isBase64URLToken(s string) bool {
+	return len(s)%4 != 1
+}
+
+// Semantic version 2.0.0 without a leading 'v'.
+// e.g. 1.0.0-alpha.1+build.5
+func isSemver(s string) bool {
+	return semverPrefixLen(s) == len(s)
+}
+
+// semverPrefixLen returns the length of the longest semantic version at the
+// beginning of s, or -1 if s doesn't start with a semantic version.
+func semverPrefixLen(s string) int {
+	var i int
+	for n := 0; n < 3; n++ {
+		if n != 0 {
+			if i == len(s) || s[i] != '.' {
+				return -1
+			}
+			i++
+		}
+		j := i
+		for j < len(s) && isDigit(rune(s[j])) {
+			j++
+		}
+		// numbers must not include leading zeros
+		if j == i || (j-i > 1 && s[i] == '0') {
+			return -1
+		}
+		i = j
+	}
+
+	// pre-release
+	if i < len(s) && s[i] == '-' {
+		if n := semverIdentifiersLen(s[i+1:], true); n != 0 {
+			i += n + 1
+		}
+	}
+
+	// build metadata
+	if i < len(s) && s[i] == '+' {
+		if n := semverIdentifiersLen(s[i+1:], false); n != 0 {
+			i += n + 1
+		}
+	}
+	return i
+}
+
+// semverIdentifiersLen returns the length of the longest dot separated
+// identifiers at the beginning of s. Numeric identifiers of pre-release must
+// not include leading zeros.
+func semverIdentifiersLen(s string, preRelease bool) (length int) {
+	var i int
+	for {
+		j, numeric := i, true
+		for j < len(s) && (isBase62(rune(s[j])) || s[j] == '-') {
+			numeric = numeric && isDigit(rune(s[j]))
+			j++
+		}
+		if j == i || (preRelease && numeric && j-i > 1 && s[i] == '0') {
+			return
+		}
+		length = j
+		if j == len(s) || s[j] != '.' {
+			return
+		}
+		i = j + 1
+	}
+}
+
 // date formant YYYY-MM-DD
 // mininum date 0000-01-01
 // maximum date 9999-12-31
@@ -171,11 +335,14 @@ func (m *SuffixMatcher) Match(str string) (offset int, matchStr string) {
 			return
 		}
 
-		// peek string to match to suffix pattern
+		// peek string to match to suffix pattern, and see if the matcher
+		// accepts the whole string before the suffix
 		if i != 0 && m.suffix == str[i:i+len(m.suffix)] {
-			offset = i + len(m.suffix)
-			matchStr = str[:i]
-			return
+			if o, s := m.matcher.Match(str[:i]); o == i {
+				offset = i + len(m.suffix)
+				matchStr = s
+				return
+			}
 		}
 
 		if !m.matcher.MatchRune(r) {
@@ -210,3 +377,99 @@ func (m *FixedLengthMatcher) Match(s string) (offset int, matchStr string) {
 	}
 	return m.length, s[:m.length]
 }
+
+// ValidatingMatcher represents a matcher that processes the given string until
+// it encounters a rune that doesn't match, and then validates the whole match.
+type ValidatingMatcher struct {
+	peek     RuneMatcherFunc
+	validate func(s string) bool
+}
+
+// MatchRune simply calls peek.
+func (m *ValidatingMatcher) MatchRune(r rune) bool {
+	return m.peek(r)
+}
+
+// Match processes the given string with the rune matcher and validates the
+// matched string.
+func (m *ValidatingMatcher) Match(str string) (offset int, matchStr string) {
+	offset, matchStr = m.peek.Match(str)
+	if offset == -1 || !m.validate(matchStr) {
+		return -1, ""
+	}
+	return
+}
+
+// UUIDOptions represents options of a matcher that NewUUIDMatcher returns.
+type UUIDOptions struct {
+	// Version requires the version nibble to be the given version and the
+	// variant to be RFC 4122. Any version and variant are accepted if it's 0.
+	Version int
+
+	// Lowercase rejects upper case hex digits.
+	Lowercase bool
+
+	// Compact accepts 32 hex digits without hyphens as well.
+	Compact bool
+}
+
+// NewUUIDMatcher returns a matcher of UUIDs with the given options. Unlike
+// UUIDMatcher, it normalises matched UUIDs into the lower case form with
+// hyphens, e.g. "9e242a66-4ea6-4323-ad5c-66a76f4472fe".
+func NewUUIDMatcher(opts UUIDOptions) Matcher {
+	return &uuidMatcher{opts}
+}
+
+type uuidMatcher struct {
+	opts UUIDOptions
+}
+
+// MatchRune see if the given rune can be a part of a UUID.
+func (m *uuidMatcher) MatchRune(r rune) bool {
+	return r == '-' || m.isHex(r)
+}
+
+func (m *uuidMatcher) isHex(r rune) bool {
+	if m.opts.Lowercase {
+		return isDigit(r) || ('a' <= r && r <= 'f')
+	}
+	return isHex(r)
+}
+
+// Match against a UUID with hyphens, or without hyphens if the matcher is
+// compact.
+func (m *uuidMatcher) Match(str string) (offset int, matchStr string) {
+	offset = -1
+	var digits string
+	if len(str) >= 36 && str[8] == '-' {
+		s := str[:36]
+		if s[13] != '-' || s[18] != '-' || s[23] != '-' {
+			return
+		}
+		digits = s[:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+		offset = 36
+	} else if m.opts.Compact && len(str) >= 32 {
+		digits = str[:32]
+		offset = 32
+	} else {
+		return
+	}
+
+	for i := 0; i < len(digits); i++ {
+		if !m.isHex(rune(digits[i])) {
+			return -1, ""
+		}
+	}
+
+	digits = strings.ToLower(digits)
+	if m.opts.Version != 0 {
+		if digits[12] != "0123456789abcdef"[m.opts.Version&0xf] ||
+			!strings.ContainsRune("89ab", rune(digits[16])) {
+			return -1, ""
+		}
+	}
+
+	matchStr = digits[:8] + "-" + digits[8:12] + "-" + digits[12:16] + "-" +
+		digits[16:20] + "-" + digits[20:]
+	return
+}
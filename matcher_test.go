@@ -67,6 +67,16 @@ func TestDefaultMatcher(t *testing.T) {
 	m.test(t)
 }
 
+func TestPathMatcher(t *testing.T) {
+	m := matcherTest{PathMatcher, []matcherTestCase{
+		{"js/app.js", 9, "js/app.js"},
+		{"/foo/", 5, "/foo/"},
+		{"日本/語", len("日本/語"), "日本/語"},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
 func TestSuffixMatcherWithIntMatcher(t *testing.T) {
 	suffixMatcher := &SuffixMatcher{"-page", IntMatcher}
 	m := matcherTest{suffixMatcher, []matcherTestCase{
@@ -163,3 +173,171 @@ func TestDateMatcher(t *testing.T) {
 	}}
 	m.test(t)
 }
+
+func TestULIDMatcher(t *testing.T) {
+	m := matcherTest{ULIDMatcher, []matcherTestCase{
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", 26, "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{"01arz3ndektsv4rrffq69g5fav/events", 26, "01arz3ndektsv4rrffq69g5fav"},
+		{"7ZZZZZZZZZZZZZZZZZZZZZZZZZ", 26, "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+		// fails
+		{"8ZZZZZZZZZZZZZZZZZZZZZZZZZ", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FA", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAI", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAL", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAO", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAU", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5FA-", -1, ""},
+		{"01ARZ3NDEKTSV4RRFFQ69G5Fあ", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestKSUIDMatcher(t *testing.T) {
+	m := matcherTest{KSUIDMatcher, []matcherTestCase{
+		{"0ujtsYcgvSTl8PAuAdqWYSMnLOv", 27, "0ujtsYcgvSTl8PAuAdqWYSMnLOv"},
+		{"0ujtsYcgvSTl8PAuAdqWYSMnLOv/items", 27, "0ujtsYcgvSTl8PAuAdqWYSMnLOv"},
+		{"000000000000000000000000000", 27, "000000000000000000000000000"},
+		{"aWgEPTl1tmebfsQzFP4bxwgy80V", 27, "aWgEPTl1tmebfsQzFP4bxwgy80V"},
+		// fails
+		{"aWgEPTl1tmebfsQzFP4bxwgy80W", -1, ""},
+		{"zzzzzzzzzzzzzzzzzzzzzzzzzzz", -1, ""},
+		{"0ujtsYcgvSTl8PAuAdqWYSMnLO", -1, ""},
+		{"0ujtsYcgvSTl8PAuAdqWYSMnLO-", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestObjectIDMatcher(t *testing.T) {
+	m := matcherTest{ObjectIDMatcher, []matcherTestCase{
+		{"507f1f77bcf86cd799439011", 24, "507f1f77bcf86cd799439011"},
+		{"507F1F77BCF86CD799439011/comments", 24, "507F1F77BCF86CD799439011"},
+		// fails
+		{"507f1f77bcf86cd79943901", -1, ""},
+		{"507f1f77bcf86cd79943901g", -1, ""},
+		{"507f1f77-bcf86cd799439011", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestSnowflakeMatcher(t *testing.T) {
+	m := matcherTest{SnowflakeMatcher, []matcherTestCase{
+		{"1541815603606036480", 19, "1541815603606036480"},
+		{"1541815603606036480/replies", 19, "1541815603606036480"},
+		{"9223372036854775807", 19, "9223372036854775807"},
+		{"1", 1, "1"},
+		// fails
+		{"9223372036854775808", -1, ""},
+		{"18446744073709551615", -1, ""},
+		{"0", -1, ""},
+		{"01541815603606036480", -1, ""},
+		{"abc", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestBase64URLMatcher(t *testing.T) {
+	m := matcherTest{Base64URLMatcher, []matcherTestCase{
+		{"dGhpcyBpcyBhIHRva2Vu", 20, "dGhpcyBpcyBhIHRva2Vu"},
+		{"-_8/reset", 3, "-_8"},
+		{"dG9rZW4", 7, "dG9rZW4"},
+		{"dG9rZW4=", 7, "dG9rZW4"},
+		// fails
+		{"dG9rZ", -1, ""},
+		{"dG9rZ+", -1, ""},
+		{"/dG9r", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestSemverMatcher(t *testing.T) {
+	m := matcherTest{SemverMatcher, []matcherTestCase{
+		{"1.0.0", 5, "1.0.0"},
+		{"0.10.200/notes", 8, "0.10.200"},
+		{"1.0.0-alpha", 11, "1.0.0-alpha"},
+		{"1.0.0-alpha.1", 13, "1.0.0-alpha.1"},
+		{"1.0.0-0.3.7", 11, "1.0.0-0.3.7"},
+		{"1.0.0-x-y-z.--", 14, "1.0.0-x-y-z.--"},
+		{"1.0.0+20130313144700", 20, "1.0.0+20130313144700"},
+		{"1.0.0-beta+exp.sha.5114f85", 26, "1.0.0-beta+exp.sha.5114f85"},
+		{"1.0.0+001", 9, "1.0.0+001"},
+		// fails
+		{"1", -1, ""},
+		{"1.0", -1, ""},
+		{"v1.0.0", -1, ""},
+		{"01.0.0", -1, ""},
+		{"1.00.0", -1, ""},
+		{"1.0.0-", -1, ""},
+		{"1.0.0-01", -1, ""},
+		{"1.0.0-alpha..1", -1, ""},
+		{"1.0.0+", -1, ""},
+		{"1.0.0.0", -1, ""},
+		{"1.0.0.tar.gz", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+
+	suffixMatcher := &SuffixMatcher{".tar.gz", SemverMatcher}
+	m = matcherTest{suffixMatcher, []matcherTestCase{
+		{"1.0.0.tar.gz", 12, "1.0.0"},
+		{"1.0.0-rc.1.tar.gz", 17, "1.0.0-rc.1"},
+		{"1.0.tar.gz", -1, ""},
+		{"1.0.0.0.tar.gz", -1, ""},
+	}}
+	m.test(t)
+}
+
+func TestUUIDOptionsMatcher(t *testing.T) {
+	m := matcherTest{UUID4Matcher, []matcherTestCase{
+		{"BE567C9C-6392-4F6D-B5AE-E35893F956BB/about", 36,
+			"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+		{"e3a596d5-b7d6-4467-8f3f-d42cdac5f1be", 36,
+			"e3a596d5-b7d6-4467-8f3f-d42cdac5f1be"},
+		// fails
+		{"e3a596d5-b7d6-1467-bf3f-d42cdac5f1be", -1, ""},
+		{"e3a596d5-b7d6-4467-cf3f-d42cdac5f1be", -1, ""},
+		{"e3a596d5b7d64467bf3fd42cdac5f1be", -1, ""},
+		{"e3a596d5-b7d6-4467-bf3f-d42cdac5f1b", -1, ""},
+		{"", -1, ""},
+	}}
+	m.test(t)
+
+	m = matcherTest{UUID7Matcher, []matcherTestCase{
+		{"01890A5D-AC96-774B-BCCE-B302099A8057", 36,
+			"01890a5d-ac96-774b-bcce-b302099a8057"},
+		// fails
+		{"be567c9c-6392-4f6d-b5ae-e35893f956bb", -1, ""},
+		{"01890a5d-ac96-774b-7cce-b302099a8057", -1, ""},
+	}}
+	m.test(t)
+
+	m = matcherTest{LowerUUIDMatcher, []matcherTestCase{
+		{"be567c9c-6392-4f6d-b5ae-e35893f956bb", 36,
+			"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+		{"00000000-0000-0000-0000-000000000000", 36,
+			"00000000-0000-0000-0000-000000000000"},
+		// fails
+		{"BE567C9C-6392-4F6D-B5AE-E35893F956BB", -1, ""},
+		{"be567c9c-6392-4f6d-b5ae-e35893f956bB", -1, ""},
+		{"be567c9c63924f6db5aee35893f956bb", -1, ""},
+	}}
+	m.test(t)
+
+	m = matcherTest{CompactUUIDMatcher, []matcherTestCase{
+		{"BE567C9C63924F6DB5AEE35893F956BB", 32,
+			"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+		{"be567c9c63924f6db5aee35893f956bb/about", 32,
+			"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+		{"BE567C9C-6392-4F6D-B5AE-E35893F956BB", 36,
+			"be567c9c-6392-4f6d-b5ae-e35893f956bb"},
+		// fails
+		{"be567c9c63924f6db5aee35893f956b", -1, ""},
+		{"be567c9c63924f6db5aee35893f956bg", -1, ""},
+		{"be567c9c-63924f6db5aee35893f956bb", -1, ""},
+	}}
+	m.test(t)
+}
@@ -0,0 +1,46 @@
+package patree
+
+// Meta is the metadata of a route, such as required scopes, a rate limit class
+// or the owner team. It's shared by requests, so it must not be modified after
+// the route is registered.
+type Meta map[string]interface{}
+
+// RouteMeta returns the metadata of the matched route. It returns nil if no
+// route matches yet or the route has no metadata.
+func (c *Context) RouteMeta() Meta {
+	return c.meta
+}
+
+// Pattern returns the matched pattern, e.g. "/posts/<int:id>". It returns an
+// empty string if no pattern matches yet.
+func (c *Context) Pattern() string {
+	return c.pattern
+}
+
+// OnMatch adds a function that is called when a pattern matches with the
+// request, before its handlers are called. It's called again if the request
+// falls through to another pattern.
+func (c *Context) OnMatch(f func(c *Context)) {
+	c.onMatch = append(c.onMatch, f)
+}
+
+// WithMeta returns a Group that registers patterns to the route with the
+// metadata, e.g.
+//
+//	r.WithMeta(patree.Meta{"scopes": []string{"admin"}}).Get("/admin", admin)
+func (r *Route) WithMeta(meta Meta) *Group {
+	return (&Group{route: r}).WithMeta(meta)
+}
+
+// WithMeta returns a nested Group that has the metadata merged with the one of
+// the group. Values of the given metadata override the ones of the group.
+func (g *Group) WithMeta(meta Meta) *Group {
+	merged := make(Meta, len(g.meta)+len(meta))
+	for k, v := range g.meta {
+		merged[k] = v
+	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+	return &Group{g.route, g.middleware, merged}
+}
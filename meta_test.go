@@ -0,0 +1,51 @@
+package patree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestRouteMeta(t *testing.T) {
+	writeMeta := func(w http.ResponseWriter, r *http.Request, c *Context) {
+		fmt.Fprintf(w, "%s %v %v", c.Pattern(), c.RouteMeta()["owner"],
+			c.RouteMeta()["scopes"])
+	}
+
+	mux := &Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		if c.Pattern() != "" || c.RouteMeta() != nil {
+			t.Fatal("pattern and metadata should be empty before matching")
+		}
+		c.Next(w, r)
+	})
+	admin := mux.WithMeta(Meta{"owner": "core", "scopes": "admin"})
+	admin.Get("/admin/posts/<int:id>", writeMeta)
+	admin.WithMeta(Meta{"owner": "billing"}).With(writeMeta).Get("/admin/billing")
+	mux.Get("/posts/<int:id>", writeMeta)
+
+	loader := &RouteLoader{Handlers: map[string]HandlerFunc{"meta": writeMeta}}
+	specs, err := DecodeJSONRoutes([]byte(`[
+  {"pattern": "/users", "handler": "meta", "meta": {"owner": "accounts"}}
+]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loader.Load(mux, "routes.json", specs); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]string{
+		"/admin/posts/10": "/admin/posts/<int:id> core admin",
+		"/admin/billing":  "/admin/billing billing admin",
+		"/posts/10":       "/posts/<int:id> <nil> <nil>",
+		"/users":          "/users accounts <nil>",
+	}
+	for urlStr, expected := range cases {
+		w := serve(mux, urlStr)
+		if body, _ := io.ReadAll(w.Body); string(body) != expected {
+			t.Fatalf("%s should have %q. Got %q instead", urlStr, expected, body)
+		}
+	}
+}
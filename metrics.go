@@ -0,0 +1,254 @@
+package patree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the upper bounds of latency histogram buckets in seconds.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records request counts, latency histograms and in-flight requests
+// labeled by methods and matched patterns rather than paths, so that the
+// number of series is bounded. Methods other than the standard ones are
+// labeled "OTHER". Requests that no pattern matches are labeled
+// with NotFoundLabel, and so are in-flight requests that are being routed.
+// Metrics serves them in the Prometheus text exposition format. The zero value
+// is ready to use.
+type Metrics struct {
+	// Namespace is the prefix of metric names, e.g. "app" for
+	// "app_http_requests_total".
+	Namespace string
+
+	// Buckets are the upper bounds of latency histogram buckets in seconds.
+	// DefaultBuckets are used if it's nil. It must not be modified after
+	// requests are recorded.
+	Buckets []float64
+
+	// NotFoundLabel is the pattern label of unmatched requests. It's
+	// "NotFound" if empty.
+	NotFoundLabel string
+
+	mu        sync.Mutex
+	requests  map[requestLabels]uint64
+	durations map[routeLabels]*histogram
+	inFlight  map[routeLabels]int64
+	now       func() time.Time
+}
+
+type routeLabels struct {
+	method, pattern string
+}
+
+type requestLabels struct {
+	routeLabels
+	status int
+}
+
+type histogram struct {
+	counts []uint64 // cumulative counts of buckets
+	sum    float64
+	count  uint64
+}
+
+// Instrument is the middleware that records requests of the following
+// handlers. Use it before any other handler.
+func (m *Metrics) Instrument(w http.ResponseWriter, r *http.Request, c *Context) {
+	now := m.clock()
+	start := now()
+	sw := c.StatusWriter(w)
+
+	labels := routeLabels{methodLabel(r.Method), m.notFoundLabel()}
+	m.addInFlight(labels, 1)
+	c.OnMatch(func(c *Context) {
+		m.addInFlight(labels, -1)
+		labels.pattern = c.Pattern()
+		m.addInFlight(labels, 1)
+	})
+	defer func() {
+		m.addInFlight(labels, -1)
+		if c.NotFound() {
+			labels.pattern = m.notFoundLabel()
+		}
+		// net/http responds 200 if nothing is written
+		status := sw.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		m.observe(requestLabels{labels, status}, now().Sub(start))
+	}()
+
+	c.Next(sw, r)
+}
+
+// methodLabel returns the method, or "OTHER" if it's not a standard method,
+// so that clients can't add series by sending arbitrary methods.
+func methodLabel(method string) string {
+	switch method {
+	case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "TRACE",
+		"CONNECT":
+		return method
+	}
+	return "OTHER"
+}
+
+func (m *Metrics) clock() func() time.Time {
+	if m.now != nil {
+		return m.now
+	}
+	return time.Now
+}
+
+func (m *Metrics) notFoundLabel() string {
+	if m.NotFoundLabel == "" {
+		return "NotFound"
+	}
+	return m.NotFoundLabel
+}
+
+func (m *Metrics) buckets() []float64 {
+	if m.Buckets == nil {
+		return DefaultBuckets
+	}
+	return m.Buckets
+}
+
+func (m *Metrics) addInFlight(labels routeLabels, n int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.inFlight == nil {
+		m.inFlight = make(map[routeLabels]int64)
+	}
+	m.inFlight[labels] += n
+}
+
+// observe records the request and its duration.
+func (m *Metrics) observe(labels requestLabels, d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.requests == nil {
+		m.requests = make(map[requestLabels]uint64)
+		m.durations = make(map[routeLabels]*histogram)
+	}
+	m.requests[labels]++
+
+	buckets := m.buckets()
+	h := m.durations[labels.routeLabels]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		m.durations[labels.routeLabels] = h
+	}
+	seconds := d.Seconds()
+	for i, upper := range buckets {
+		if seconds <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format. Series
+// are sorted by their labels.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var b strings.Builder
+	prefix := "http_"
+	if m.Namespace != "" {
+		prefix = m.Namespace + "_http_"
+	}
+
+	name := prefix + "requests_total"
+	fmt.Fprintf(&b, "# HELP %s Total number of HTTP requests.\n", name)
+	fmt.Fprintf(&b, "# TYPE %s counter\n", name)
+	requests := make([]requestLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		requests = append(requests, labels)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		a, b := requests[i], requests[j]
+		if a.routeLabels != b.routeLabels {
+			return a.routeLabels.less(b.routeLabels)
+		}
+		return a.status < b.status
+	})
+	for _, labels := range requests {
+		fmt.Fprintf(&b, "%s{%s,status=\"%d\"} %d\n", name, labels.String(),
+			labels.status, m.requests[labels])
+	}
+
+	name = prefix + "request_duration_seconds"
+	fmt.Fprintf(&b, "# HELP %s Latency of HTTP requests in seconds.\n", name)
+	fmt.Fprintf(&b, "# TYPE %s histogram\n", name)
+	buckets := m.buckets()
+	for _, labels := range sortedRouteLabels(m.durations) {
+		h := m.durations[labels]
+		for i, upper := range buckets {
+			fmt.Fprintf(&b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels.String(),
+				formatFloat(upper), h.counts[i])
+		}
+		fmt.Fprintf(&b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels.String(),
+			h.count)
+		fmt.Fprintf(&b, "%s_sum{%s} %s\n", name, labels.String(),
+			formatFloat(h.sum))
+		fmt.Fprintf(&b, "%s_count{%s} %d\n", name, labels.String(), h.count)
+	}
+
+	name = prefix + "requests_in_flight"
+	fmt.Fprintf(&b, "# HELP %s Number of HTTP requests being served.\n", name)
+	fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+	for _, labels := range sortedRouteLabels(m.inFlight) {
+		fmt.Fprintf(&b, "%s{%s} %d\n", name, labels.String(), m.inFlight[labels])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (l routeLabels) less(o routeLabels) bool {
+	if l.pattern != o.pattern {
+		return l.pattern < o.pattern
+	}
+	return l.method < o.method
+}
+
+// String returns the labels in the exposition format.
+func (l routeLabels) String() string {
+	return `method="` + escapeLabel(l.method) + `",pattern="` +
+		escapeLabel(l.pattern) + `"`
+}
+
+// sortedRouteLabels returns the sorted keys of the map.
+func sortedRouteLabels[V any](series map[routeLabels]V) []routeLabels {
+	labels := make([]routeLabels, 0, len(series))
+	for l := range series {
+		labels = append(labels, l)
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].less(labels[j])
+	})
+	return labels
+}
+
+// escapeLabel escapes the label value of the exposition format.
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
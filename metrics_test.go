@@ -0,0 +1,97 @@
+package patree
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := &Metrics{Namespace: "app", Buckets: []float64{0.1, 1}}
+	m.now = func() time.Time {
+		now = now.Add(250 * time.Millisecond)
+		return now
+	}
+
+	var inFlight string
+	mux := &Route{}
+	mux.Use(m.Instrument)
+	mux.Get("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		var b strings.Builder
+		m.WriteTo(&b)
+		inFlight = b.String()
+	})
+	mux.Post("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.NotFound(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		http.NotFound(w, r)
+	})
+
+	for _, req := range []struct{ method, urlStr string }{
+		{"GET", "/posts/1"}, {"GET", "/posts/2"}, {"POST", "/posts/3"},
+		{"GET", "/users/1"}, {"GET", "/users/2"}, {"BREW", "/posts/4"},
+		{"X1", "/posts/5"},
+	} {
+		mux.ServeHTTP(httptest.NewRecorder(),
+			httptest.NewRequest(req.method, req.urlStr, nil))
+	}
+
+	if !strings.Contains(inFlight, `app_http_requests_in_flight{method="GET",pattern="/posts/<int:id>"} 1`) {
+		t.Fatalf("in-flight request should be labeled with the pattern. Got\n%s", inFlight)
+	}
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	expected := `# HELP app_http_requests_total Total number of HTTP requests.
+# TYPE app_http_requests_total counter
+app_http_requests_total{method="GET",pattern="/posts/<int:id>",status="200"} 2
+app_http_requests_total{method="POST",pattern="/posts/<int:id>",status="201"} 1
+app_http_requests_total{method="GET",pattern="NotFound",status="404"} 2
+app_http_requests_total{method="OTHER",pattern="NotFound",status="404"} 2
+# HELP app_http_request_duration_seconds Latency of HTTP requests in seconds.
+# TYPE app_http_request_duration_seconds histogram
+app_http_request_duration_seconds_bucket{method="GET",pattern="/posts/<int:id>",le="0.1"} 0
+app_http_request_duration_seconds_bucket{method="GET",pattern="/posts/<int:id>",le="1"} 2
+app_http_request_duration_seconds_bucket{method="GET",pattern="/posts/<int:id>",le="+Inf"} 2
+app_http_request_duration_seconds_sum{method="GET",pattern="/posts/<int:id>"} 0.5
+app_http_request_duration_seconds_count{method="GET",pattern="/posts/<int:id>"} 2
+app_http_request_duration_seconds_bucket{method="POST",pattern="/posts/<int:id>",le="0.1"} 0
+app_http_request_duration_seconds_bucket{method="POST",pattern="/posts/<int:id>",le="1"} 1
+app_http_request_duration_seconds_bucket{method="POST",pattern="/posts/<int:id>",le="+Inf"} 1
+app_http_request_duration_seconds_sum{method="POST",pattern="/posts/<int:id>"} 0.25
+app_http_request_duration_seconds_count{method="POST",pattern="/posts/<int:id>"} 1
+app_http_request_duration_seconds_bucket{method="GET",pattern="NotFound",le="0.1"} 0
+app_http_request_duration_seconds_bucket{method="GET",pattern="NotFound",le="1"} 2
+app_http_request_duration_seconds_bucket{method="GET",pattern="NotFound",le="+Inf"} 2
+app_http_request_duration_seconds_sum{method="GET",pattern="NotFound"} 0.5
+app_http_request_duration_seconds_count{method="GET",pattern="NotFound"} 2
+app_http_request_duration_seconds_bucket{method="OTHER",pattern="NotFound",le="0.1"} 0
+app_http_request_duration_seconds_bucket{method="OTHER",pattern="NotFound",le="1"} 2
+app_http_request_duration_seconds_bucket{method="OTHER",pattern="NotFound",le="+Inf"} 2
+app_http_request_duration_seconds_sum{method="OTHER",pattern="NotFound"} 0.5
+app_http_request_duration_seconds_count{method="OTHER",pattern="NotFound"} 2
+# HELP app_http_requests_in_flight Number of HTTP requests being served.
+# TYPE app_http_requests_in_flight gauge
+app_http_requests_in_flight{method="GET",pattern="/posts/<int:id>"} 0
+app_http_requests_in_flight{method="POST",pattern="/posts/<int:id>"} 0
+app_http_requests_in_flight{method="GET",pattern="NotFound"} 0
+app_http_requests_in_flight{method="OTHER",pattern="NotFound"} 0
+app_http_requests_in_flight{method="POST",pattern="NotFound"} 0
+`
+	if w.Body.String() != expected {
+		t.Fatalf("metrics should be\n%s\nGot\n%s\ninstead", expected, w.Body.String())
+	}
+	if ctype := w.Header().Get("Content-Type"); !strings.HasPrefix(ctype, "text/plain; version=0.0.4") {
+		t.Fatalf("metrics should be the text format. Got %s instead", ctype)
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if s := escapeLabel("a\\b\"c\nd"); s != `a\\b\"c\nd` {
+		t.Fatalf("label should be escaped. Got %s instead", s)
+	}
+}
@@ -0,0 +1,330 @@
+package patree
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+)
+
+// OpenAPIMethods are the methods of OpenAPI 3 operations. A handler that
+// matches with any method is exported as operations of these methods.
+var OpenAPIMethods = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS",
+	"HEAD", "PATCH", "TRACE"}
+
+// OpenAPIPathItem is an OpenAPI 3 path item object.
+type OpenAPIPathItem struct {
+	Summary     string              `json:"summary,omitempty"`
+	Description string              `json:"description,omitempty"`
+	Get         *OpenAPIOperation   `json:"get,omitempty"`
+	Put         *OpenAPIOperation   `json:"put,omitempty"`
+	Post        *OpenAPIOperation   `json:"post,omitempty"`
+	Delete      *OpenAPIOperation   `json:"delete,omitempty"`
+	Options     *OpenAPIOperation   `json:"options,omitempty"`
+	Head        *OpenAPIOperation   `json:"head,omitempty"`
+	Patch       *OpenAPIOperation   `json:"patch,omitempty"`
+	Trace       *OpenAPIOperation   `json:"trace,omitempty"`
+	Parameters  []*OpenAPIParameter `json:"parameters,omitempty"`
+}
+
+// Operation returns the pointer to the operation field of the method, or nil
+// if the method isn't one of OpenAPIMethods.
+func (item *OpenAPIPathItem) Operation(method string) **OpenAPIOperation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return &item.Get
+	case "PUT":
+		return &item.Put
+	case "POST":
+		return &item.Post
+	case "DELETE":
+		return &item.Delete
+	case "OPTIONS":
+		return &item.Options
+	case "HEAD":
+		return &item.Head
+	case "PATCH":
+		return &item.Patch
+	case "TRACE":
+		return &item.Trace
+	}
+	return nil
+}
+
+// OpenAPIOperation is an OpenAPI 3 operation object. RequestBody and
+// Responses are marshaled as they are, so that any request body and response
+// objects can be attached.
+type OpenAPIOperation struct {
+	OperationID string                 `json:"operationId,omitempty"`
+	Summary     string                 `json:"summary,omitempty"`
+	Description string                 `json:"description,omitempty"`
+	Tags        []string               `json:"tags,omitempty"`
+	Parameters  []*OpenAPIParameter    `json:"parameters,omitempty"`
+	RequestBody interface{}            `json:"requestBody,omitempty"`
+	Responses   map[string]interface{} `json:"responses"`
+	Deprecated  bool                   `json:"deprecated,omitempty"`
+}
+
+// OpenAPIParameter is an OpenAPI 3 parameter object.
+type OpenAPIParameter struct {
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+	Schema      *OpenAPISchema `json:"schema,omitempty"`
+}
+
+// OpenAPISchema is an OpenAPI 3 schema object of a path parameter.
+type OpenAPISchema struct {
+	Type    string `json:"type,omitempty"`
+	Format  string `json:"format,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// OpenAPIHook is called with every exported operation, so that summaries,
+// request bodies and responses can be attached to the operation. The pattern
+// is the registered pattern string, e.g. "/foo/<int:bar>".
+type OpenAPIHook func(method, pattern string, op *OpenAPIOperation)
+
+// openAPISchemas are schemas of the built-in match types. Match types that
+// aren't listed are exported as strings.
+var openAPISchemas = map[string]OpenAPISchema{
+	"int":          {Type: "integer"},
+	"snowflake":    {Type: "integer", Format: "int64"},
+	"hex":          {Type: "string", Pattern: "^[0-9a-fA-F]+$"},
+	"uuid":         {Type: "string", Format: "uuid"},
+	"uuid4":        {Type: "string", Format: "uuid"},
+	"uuid7":        {Type: "string", Format: "uuid"},
+	"uuid_lower":   {Type: "string", Format: "uuid", Pattern: "^[0-9a-f-]{36}$"},
+	"uuid_compact": {Type: "string", Pattern: "^[0-9a-fA-F]{8}(-?[0-9a-fA-F]{4}){3}-?[0-9a-fA-F]{12}$"},
+	"date":         {Type: "string", Format: "date"},
+	"ulid":         {Type: "string", Pattern: "^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$"},
+	"ksuid":        {Type: "string", Pattern: "^[0-9A-Za-z]{27}$"},
+	"objectid":     {Type: "string", Pattern: "^[0-9a-fA-F]{24}$"},
+	"base64url":    {Type: "string", Pattern: "^[A-Za-z0-9_-]+$"},
+	"path":         {Type: "string"},
+	"semver": {Type: "string", Pattern: `^(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)` +
+		`(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?` +
+		`(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`},
+}
+
+// OpenAPIPaths returns the OpenAPI 3 paths object of the patterns registered
+// to the route. Match patterns are converted to path templates, e.g.
+// "/foo/<int:bar>" is "/foo/{bar}" that has an integer path parameter "bar".
+// Operations are listed from the methods of the handlers. The hook may be nil.
+func (r *Route) OpenAPIPaths(hook OpenAPIHook) map[string]*OpenAPIPathItem {
+	paths := make(map[string]*OpenAPIPathItem)
+	for _, entry := range r.Entries() {
+		entry.addOpenAPIPaths(paths, hook)
+	}
+	return paths
+}
+
+// addOpenAPIPaths adds operations of the entry and its descendants to the
+// paths in the order they are evaluated.
+func (e *Entry) addOpenAPIPaths(paths map[string]*OpenAPIPathItem, hook OpenAPIHook) {
+	for _, method := range e.Methods() {
+		h := e.handlers[method]
+		methods := []string{method}
+		if method == "*" {
+			h = e.handler
+			methods = nil
+			for _, m := range OpenAPIMethods {
+				if e.handlers[m] == nil {
+					methods = append(methods, m)
+				}
+			}
+		}
+
+		template, params := openAPITemplate(h.pattern)
+		item := paths[template]
+		if item == nil {
+			item = &OpenAPIPathItem{}
+			paths[template] = item
+		}
+		for _, m := range methods {
+			op := item.Operation(m)
+			// the former pattern is evaluated first
+			if op == nil || *op != nil {
+				continue
+			}
+			*op = &OpenAPIOperation{
+				Parameters: params,
+				Responses: map[string]interface{}{
+					"default": map[string]string{"description": "Default response"},
+				},
+			}
+			if hook != nil {
+				hook(m, h.pattern, *op)
+			}
+		}
+	}
+
+	for _, child := range e.entries {
+		child.addOpenAPIPaths(paths, hook)
+	}
+}
+
+// openAPITemplate returns the OpenAPI path template and path parameters of
+// the pattern.
+func openAPITemplate(pat string) (string, []*OpenAPIParameter) {
+	patterns, _ := SplitPath(pat)
+	var template string
+	var params []*OpenAPIParameter
+	for _, p := range patterns {
+		if !isMatchPattern(p) {
+			template += p
+			continue
+		}
+		matchType, name := splitMatchPattern(p)
+		schema, ok := openAPISchemas[matchType]
+		if !ok {
+			schema = OpenAPISchema{Type: "string"}
+		}
+		template += "{" + name + "}"
+		params = append(params, &OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &schema,
+		})
+	}
+	return template, params
+}
+
+// OpenAPIDocument is an OpenAPI 3 document. Only paths are decoded.
+type OpenAPIDocument struct {
+	Paths map[string]*OpenAPIPathItem `json:"paths"`
+}
+
+// UnboundOperation is an operation of an OpenAPI document that has no
+// handler.
+type UnboundOperation struct {
+	Method      string
+	Path        string
+	OperationID string
+}
+
+// ImportOpenAPI registers the operations of the OpenAPI 3 document in JSON to
+// the route. A YAML document can be imported after converting it to JSON. See
+// ImportOpenAPIPaths for how operations are registered.
+func (r *Route) ImportOpenAPI(data []byte, handlers map[string]HandlerFunc) ([]UnboundOperation, error) {
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return r.ImportOpenAPIPaths(doc.Paths, handlers)
+}
+
+// ImportOpenAPIPaths registers the operations of the OpenAPI 3 paths object to
+// the route. Path templates are converted to patterns by the schemas of path
+// parameters, e.g. "/foo/{bar}" that has an integer path parameter "bar" is
+// "/foo/<int:bar>". Parameters of "uuid" and "date" formats are "uuid" and
+// "date" match types, and the others are the default match type. Each
+// operation is registered with the handler of its operationId. Operations that
+// have no handler are returned, and aren't registered. Null path items are
+// skipped. Nothing is registered if any operation fails to be registered.
+func (r *Route) ImportOpenAPIPaths(paths map[string]*OpenAPIPathItem, handlers map[string]HandlerFunc) ([]UnboundOperation, error) {
+	templates := make([]string, 0, len(paths))
+	for template := range paths {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+
+	type operation struct {
+		method, template, pattern string
+		handler                   HandlerFunc
+	}
+	var ops []operation
+	var unbound []UnboundOperation
+	for _, template := range templates {
+		item := paths[template]
+		if item == nil {
+			continue
+		}
+		for _, method := range OpenAPIMethods {
+			op := *item.Operation(method)
+			if op == nil {
+				continue
+			}
+			h := handlers[op.OperationID]
+			if op.OperationID == "" || h == nil {
+				unbound = append(unbound,
+					UnboundOperation{method, template, op.OperationID})
+				continue
+			}
+
+			pat, err := openAPIPattern(template, item.Parameters, op.Parameters)
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, operation{method, template, pat, h})
+		}
+	}
+
+	// operations are registered to a copy of the route first to detect
+	// duplicate registrations
+	for _, route := range []*Route{r.clone(), r} {
+		for _, op := range ops {
+			err := route.handle(op.pattern, op.method, []HandlerFunc{op.handler}, nil)
+			if err != nil {
+				return nil, errors.New(op.method + " " + op.template + ": " +
+					err.Error())
+			}
+		}
+	}
+	return unbound, nil
+}
+
+// openAPIPattern returns the pattern of the OpenAPI path template. Operation
+// parameters override path item parameters of the same name.
+func openAPIPattern(template string, itemParams, opParams []*OpenAPIParameter) (string, error) {
+	schemas := make(map[string]*OpenAPISchema)
+	for _, params := range [][]*OpenAPIParameter{itemParams, opParams} {
+		for _, p := range params {
+			if p != nil && p.In == "path" {
+				schemas[p.Name] = p.Schema
+			}
+		}
+	}
+
+	var pat string
+	s := template
+	for s != "" {
+		i := strings.IndexAny(s, "{<>")
+		if i == -1 {
+			pat += s
+			break
+		}
+		if s[i] != '{' {
+			return "", errors.New(template + ": '<' and '>' can't be used in paths")
+		}
+		j := strings.IndexByte(s[i:], '}')
+		if j == -1 {
+			return "", errors.New(template + ": no closing brace")
+		}
+		name := s[i+1 : i+j]
+		if matchType := openAPIMatchType(schemas[name]); matchType != "default" {
+			name = matchType + ":" + name
+		}
+		pat += s[:i] + "<" + name + ">"
+		s = s[i+j+1:]
+	}
+	return pat, nil
+}
+
+// openAPIMatchType returns the match type of the path parameter schema.
+func openAPIMatchType(schema *OpenAPISchema) string {
+	if schema == nil {
+		return "default"
+	}
+	switch {
+	case schema.Type == "integer":
+		return "int"
+	case schema.Format == "uuid":
+		return "uuid"
+	case schema.Format == "date":
+		return "date"
+	}
+	return "default"
+}
@@ -0,0 +1,181 @@
+package patree
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOpenAPIPaths(t *testing.T) {
+	mux := &Route{}
+	mux.Get("/foo/<int:bar>", foobar)
+	mux.Delete("/foo/<int:bar>", foobar)
+	mux.Post("/foo/<int:bar>/comments/<uuid:comment_id>", foobar)
+	mux.Get("/posts/<date:date>/<slug>.html", foobar)
+	mux.HandleMethod("/posts/<hex:id>", "GET", foobar)
+	mux.Use(foobar)
+	mux.Post("/webhooks", foobar)
+	mux.Handle("/webhooks", foobar)
+
+	paths := mux.OpenAPIPaths(func(method, pattern string, op *OpenAPIOperation) {
+		if pattern == "/foo/<int:bar>" && method == "GET" {
+			op.Summary = "Get foo"
+			op.Responses["200"] = map[string]interface{}{
+				"description": "foo",
+			}
+		}
+	})
+
+	data, err := json.Marshal(paths["/foo/{bar}"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"get":{"summary":"Get foo","parameters":[{"name":"bar","in":"path","required":true,"schema":{"type":"integer"}}],"responses":{"200":{"description":"foo"},"default":{"description":"Default response"}}},` +
+		`"delete":{"parameters":[{"name":"bar","in":"path","required":true,"schema":{"type":"integer"}}],"responses":{"default":{"description":"Default response"}}},` +
+		`"head":{"parameters":[{"name":"bar","in":"path","required":true,"schema":{"type":"integer"}}],"responses":{"default":{"description":"Default response"}}}}`
+	if string(data) != expected {
+		t.Fatalf("Got %s instead of expected %s", data, expected)
+	}
+
+	cases := []struct {
+		template string
+		method   string
+		params   []OpenAPISchema
+	}{
+		{"/foo/{bar}/comments/{comment_id}", "POST", []OpenAPISchema{
+			{Type: "integer"}, {Type: "string", Format: "uuid"}}},
+		{"/posts/{date}/{slug}.html", "HEAD", []OpenAPISchema{
+			{Type: "string", Format: "date"}, {Type: "string"}}},
+		{"/posts/{id}", "GET", []OpenAPISchema{
+			{Type: "string", Pattern: "^[0-9a-fA-F]+$"}}},
+		{"/webhooks", "POST", nil},
+		{"/webhooks", "TRACE", nil},
+	}
+	for _, tc := range cases {
+		item := paths[tc.template]
+		if item == nil {
+			t.Fatalf("paths should have %s", tc.template)
+		}
+		op := *item.Operation(tc.method)
+		if op == nil {
+			t.Fatalf("%s should have %s operation", tc.template, tc.method)
+		}
+		if len(op.Parameters) != len(tc.params) {
+			t.Fatalf("%s should have %d parameters", tc.template, len(tc.params))
+		}
+		for i, p := range op.Parameters {
+			if *p.Schema != tc.params[i] {
+				t.Fatalf("%s parameter %s should have schema %v. Got %v instead",
+					tc.template, p.Name, tc.params[i], *p.Schema)
+			}
+		}
+	}
+
+	if paths["/posts/{id}"].Head != nil {
+		t.Fatal("HandleMethod should only export the method")
+	}
+	if len(paths) != 5 {
+		t.Fatalf("paths should have 5 path items. Got %d instead", len(paths))
+	}
+}
+
+func TestImportOpenAPI(t *testing.T) {
+	doc := `{
+  "openapi": "3.0.3",
+  "info": {"title": "test", "version": "1.0.0"},
+  "paths": {
+    "/users/{id}": {
+      "parameters": [{"name": "id", "in": "path", "required": true,
+        "schema": {"type": "integer", "format": "int64"}}],
+      "get": {"operationId": "getUser", "responses": {}},
+      "delete": {"operationId": "deleteUser", "responses": {}}
+    },
+    "/users/{id}/files/{file_id}.json": {
+      "get": {
+        "operationId": "getFile",
+        "parameters": [
+          {"name": "id", "in": "path", "schema": {"type": "integer"}},
+          {"name": "file_id", "in": "path",
+            "schema": {"type": "string", "format": "uuid"}}
+        ],
+        "responses": {}
+      }
+    },
+    "/archives/{date}/{slug}": {
+      "parameters": [
+        {"name": "date", "in": "path", "schema": {"type": "string", "format": "date"}},
+        {"name": "slug", "in": "path", "schema": {"type": "string"}}
+      ],
+      "get": {"operationId": "getArchive", "responses": {}},
+      "post": {"responses": {}}
+    }
+  }
+}`
+
+	handlers := map[string]HandlerFunc{
+		"getUser":    foobar,
+		"getFile":    foobar,
+		"getArchive": foobar,
+	}
+	mux := &Route{}
+	unbound, err := mux.ImportOpenAPI([]byte(doc), handlers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []UnboundOperation{
+		{"POST", "/archives/{date}/{slug}", ""},
+		{"DELETE", "/users/{id}", "deleteUser"},
+	}
+	if !reflect.DeepEqual(unbound, expected) {
+		t.Fatalf("Got %v instead of expected %v", unbound, expected)
+	}
+
+	cases := []struct {
+		method, urlStr, pattern string
+	}{
+		{"GET", "/users/10", "/users/<int:id>"},
+		{"GET", "/users/10/files/be567c9c-6392-4f6d-b5ae-e35893f956bb.json",
+			"/users/<int:id>/files/<uuid:file_id>.json"},
+		{"GET", "/archives/2014-01-01/golang", "/archives/<date:date>/<slug>"},
+		{"GET", "/users/foo", ""},
+		{"DELETE", "/users/10", ""},
+		{"POST", "/archives/2014-01-01/golang", ""},
+	}
+	for _, tc := range cases {
+		pat, _, _ := mux.Match(tc.method, tc.urlStr)
+		if pat != tc.pattern {
+			t.Fatalf("%s %s should match %s. Got %s instead", tc.method,
+				tc.urlStr, tc.pattern, pat)
+		}
+	}
+
+	_, err = (&Route{}).ImportOpenAPIPaths(map[string]*OpenAPIPathItem{
+		"/users/{id": {Get: &OpenAPIOperation{OperationID: "getUser"}},
+	}, handlers)
+	if err == nil {
+		t.Fatal("template without closing brace should be an error")
+	}
+
+	mux = &Route{}
+	mux.Get("/users/<int:id>", foobar)
+	_, err = mux.ImportOpenAPIPaths(map[string]*OpenAPIPathItem{
+		"/archives/{slug}": {Get: &OpenAPIOperation{OperationID: "getArchive"}},
+		"/users/{id}": {Get: &OpenAPIOperation{OperationID: "getUser",
+			Parameters: []*OpenAPIParameter{{Name: "id", In: "path",
+				Schema: &OpenAPISchema{Type: "integer"}}}}},
+	}, handlers)
+	if err == nil {
+		t.Fatal("duplicate operation should be an error")
+	}
+	if _, _, ok := mux.Match("GET", "/archives/golang"); ok {
+		t.Fatal("nothing should be registered if an operation fails")
+	}
+
+	unbound, err = (&Route{}).ImportOpenAPI([]byte(`{"paths": {"/x": null}}`),
+		handlers)
+	if err != nil || len(unbound) != 0 {
+		t.Fatalf("null path item should be skipped. Got %v %v instead",
+			unbound, err)
+	}
+}
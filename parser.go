@@ -4,7 +4,6 @@ import (
 	"bufio"
 	"bytes"
 	"errors"
-	"io"
 	"strings"
 	"unicode/utf8"
 )
@@ -18,23 +17,68 @@ var NoClosingBracket = errors.New("Invalid syntax: No closing bracket found")
 // Pattern "<hex:id> is a HexMatcher.
 // Pattern "<id>" is a DefaultMatcher.
 // Pattern "<uuid:id>" is a UUIDMatcher
-var MatcherMap = map[string]Matcher{
-	"default": DefaultMatcher,
-	"int":     IntMatcher,
-	"hex":     HexMatcher,
-	"uuid":    UUIDMatcher,
-	"date":    DateMatcher,
+// Pattern "<semver:v>" is a SemverMatcher, and so on for "date", "ulid",
+// "ksuid", "objectid", "snowflake" and "base64url".
+// Pattern "<uuid4:id>" is a UUID4Matcher, and so on for "uuid7", "uuid_lower"
+// and "uuid_compact". They normalise the matched UUID into lower case.
+// Pattern "<path:p>" is a PathMatcher that matches the rest of the path.
+// MatcherMap is shared by every Route that isn't created with a
+// MatcherRegistry, and it is not safe to modify while routes are registered.
+var MatcherMap = newMatcherMap()
+
+// newMatcherMap returns a new map of the built-in Matchers.
+func newMatcherMap() map[string]Matcher {
+	return map[string]Matcher{
+		"default":   DefaultMatcher,
+		"int":       IntMatcher,
+		"hex":       HexMatcher,
+		"uuid":      UUIDMatcher,
+		"date":      DateMatcher,
+		"ulid":      ULIDMatcher,
+		"ksuid":     KSUIDMatcher,
+		"objectid":  ObjectIDMatcher,
+		"snowflake": SnowflakeMatcher,
+		"base64url": Base64URLMatcher,
+		"semver":    SemverMatcher,
+		"path":      PathMatcher,
+
+		"uuid4":        UUID4Matcher,
+		"uuid7":        UUID7Matcher,
+		"uuid_lower":   LowerUUIDMatcher,
+		"uuid_compact": CompactUUIDMatcher,
+	}
 }
 
-// parseMatcher returns matcher and name from the given pattern string.
-func parseMatcher(pat string) (matcher Matcher, name string) {
+// parseMatcher returns matcher and name from the given pattern string. The
+// match type is resolved through the registry.
+func (m *MatcherRegistry) parseMatcher(pat string) (matcher Matcher, name string) {
 	if !isMatchPattern(pat) {
 		panic("pattern \"" + pat + "\" is not a matcher pattern")
 	}
+	matcher, name, err := m.lookupMatcher(pat)
+	if err != nil {
+		panic(err)
+	}
+	return matcher, name
+}
+
+// lookupMatcher returns matcher and name from the given match pattern string,
+// or an error if there is no such match type.
+func (m *MatcherRegistry) lookupMatcher(pat string) (matcher Matcher, name string, err error) {
+	matchType, name := splitMatchPattern(pat)
+	matcher = m.Lookup(matchType)
+	if matcher == nil {
+		return nil, "", errors.New("no such match type: " + matchType)
+	}
 
+	return matcher, name, nil
+}
+
+// splitMatchPattern returns match type and name from the given match pattern
+// string. The match type of "<name>" is "default".
+func splitMatchPattern(pat string) (matchType, name string) {
 	s := pat[1 : len(pat)-1]
 	ss := strings.Split(s, ":")
-	var matchType string
 	if len(ss) == 1 {
 		name = ss[0]
 	} else {
@@ -44,13 +88,41 @@ func parseMatcher(pat string) (matcher Matcher, name string) {
 	if matchType == "" {
 		matchType = "default"
 	}
+	return
+}
 
-	matcher = MatcherMap[matchType]
-	if matcher == nil {
-		panic(errors.New("no such match type: " + matchType))
+// ParseMatchPattern returns the match type and name of the match pattern, e.g.
+// "int" and "id" of "<int:id>". The match type of "<id>" is "default". ok is
+// false if the pattern isn't a match pattern such as a static pattern of
+// SplitPath.
+func ParseMatchPattern(pat string) (matchType, name string, ok bool) {
+	if !isMatchPattern(pat) {
+		return "", "", false
 	}
+	matchType, name = splitMatchPattern(pat)
+	return matchType, name, true
+}
 
-	return matcher, name
+// CheckPattern returns an error if the url pattern can't be registered to a
+// Route with the registry, that is, it has a syntax error or an unknown match
+// type. A nil registry checks match types with MatcherMap.
+func (m *MatcherRegistry) CheckPattern(pat string) error {
+	patterns, err := SplitPath(pat)
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		return errors.New("Invalid syntax: empty pattern")
+	}
+	for _, p := range patterns {
+		if !isMatchPattern(p) {
+			continue
+		}
+		if _, _, err := m.lookupMatcher(p); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // isMatchPattern see if given string is match pattern.
@@ -58,38 +130,40 @@ func isMatchPattern(s string) bool {
 	return len(s) > 2 && s[0] == '<' && s[len(s)-1] == '>'
 }
 
-// routeSplitFunc is the SplitFunc to scan url pattern.
+// routeSplitFunc is the SplitFunc to scan url pattern. It requests more data
+// rather than splits a pattern at the end of the buffer unless atEOF.
 func routeSplitFunc(data []byte, atEOF bool) (int, []byte, error) {
-	if atEOF || data == nil {
-		return 0, nil, io.EOF
+	if len(data) == 0 {
+		return 0, nil, nil
 	}
 
 	r, _ := utf8.DecodeRune(data)
 
 	// matcher
 	if r == '<' {
-		i := bytes.IndexRune(data, '>')
+		i := bytes.IndexByte(data, '>')
 		if i == -1 {
+			if !atEOF {
+				return 0, nil, nil
+			}
 			return 0, nil, NoClosingBracket
 		}
 		return (i + 1), data[:(i + 1)], nil
 	}
 
-	// one char
-	if len(data) == 1 {
-		return 1, data, nil
-	}
-
 	// should ignore first '/'
-	slashIndex := bytes.IndexRune(data[1:], '/')
+	slashIndex := bytes.IndexByte(data[1:], '/')
 	if slashIndex != -1 {
 		slashIndex++
 	}
 
-	matchIndex := bytes.IndexRune(data, '<')
+	matchIndex := bytes.IndexByte(data, '<')
 
 	// remaining string would be a static entry
 	if slashIndex == -1 && matchIndex == -1 {
+		if !atEOF {
+			return 0, nil, nil
+		}
 		return len(data), data, nil
 	}
 
@@ -107,6 +181,8 @@ func routeSplitFunc(data []byte, atEOF bool) (int, []byte, error) {
 // SplitPath splits the url pattern.
 func SplitPath(pat string) (routes []string, err error) {
 	scanner := bufio.NewScanner(strings.NewReader(pat))
+	// a pattern can be a single token
+	scanner.Buffer(nil, len(pat)+1)
 	scanner.Split(routeSplitFunc)
 	for scanner.Scan() {
 		routes = append(routes, scanner.Text())
@@ -121,9 +197,9 @@ func SplitPath(pat string) (routes []string, err error) {
 //   2. the next pattern is a static pattern.
 // If the first matcher can't match the first rune of the second static pattern,
 // next pattern should be a suffix matcher combined the two patterns.
-func isNextSuffixPattern(p []string) bool {
+func isNextSuffixPattern(p []string, m *MatcherRegistry) bool {
 	if len(p) >= 2 && isMatchPattern(p[0]) && !isMatchPattern(p[1]) {
-		matcher, _ := parseMatcher(p[0])
+		matcher, _ := m.parseMatcher(p[0])
 		if _, ok := matcher.(*FixedLengthMatcher); ok {
 			return false
 		}
@@ -133,9 +209,16 @@ func isNextSuffixPattern(p []string) bool {
 	return false
 }
 
-// PeekNextPattern returns next entry pattern with offset size
+// PeekNextPattern returns next entry pattern with offset size. Match types are
+// resolved through MatcherMap.
 func PeekNextPattern(p []string) (pat string, size int) {
-	if isNextSuffixPattern(p) {
+	return peekNextPattern(p, nil)
+}
+
+// peekNextPattern returns next entry pattern with offset size. Match types are
+// resolved through the given registry.
+func peekNextPattern(p []string, m *MatcherRegistry) (pat string, size int) {
+	if isNextSuffixPattern(p, m) {
 		pat, size = (p[0] + p[1]), 2
 	} else {
 		pat, size = p[0], 1
@@ -2,6 +2,7 @@ package patree
 
 import (
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -16,6 +17,12 @@ func TestSplitPath(t *testing.T) {
 		"/foo/<int:bar>-page/about": {"/foo/", "<int:bar>", "-page/", "about"},
 		"/<int:bar>/about":          {"/", "<int:bar>", "/about"},
 	}
+	// patterns that are longer than the buffer of bufio.Scanner
+	long := strings.Repeat("a", 5000)
+	cases["/"+long+"/<id>"] = []string{"/" + long + "/", "<id>"}
+	cases["/<"+long+">"] = []string{"/", "<" + long + ">"}
+	cases["/"+long+long+long] = []string{"/" + long + long + long}
+
 	for p, expected := range cases {
 		ret, err := SplitPath(p)
 		if err != nil {
@@ -41,3 +48,26 @@ func TestSplitPath(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMatchPattern(t *testing.T) {
+	cases := []struct {
+		pat       string
+		matchType string
+		name      string
+		ok        bool
+	}{
+		{"<int:id>", "int", "id", true},
+		{"<id>", "default", "id", true},
+		{"<:id>", "default", "id", true},
+		{"<a:b:c>", "a", "b", true},
+		{"/posts/", "", "", false},
+		{"<>", "", "", false},
+	}
+	for _, tc := range cases {
+		matchType, name, ok := ParseMatchPattern(tc.pat)
+		if matchType != tc.matchType || name != tc.name || ok != tc.ok {
+			t.Fatalf("%s should be %q %q %v. Got %q %q %v instead", tc.pat,
+				tc.matchType, tc.name, tc.ok, matchType, name, ok)
+		}
+	}
+}
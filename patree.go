@@ -2,7 +2,9 @@
 package patree
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 )
 
 // Handler is a http Handler with context
@@ -19,15 +21,63 @@ func (f HandlerFunc) ServeHTTPContext(w http.ResponseWriter, r *http.Request, c
 	f(w, r, c)
 }
 
+// handlerFunc returns the HandlerFunc of a handler that is passed to Use,
+// Handle and the other registration methods. The handler is a HandlerFunc, a
+// HandlerFuncE, a function of either signature or a Handler. It panics if the
+// handler is none of them.
+func handlerFunc(f interface{}) HandlerFunc {
+	switch f := f.(type) {
+	case HandlerFunc:
+		return f
+	case func(http.ResponseWriter, *http.Request, *Context):
+		return f
+	case HandlerFuncE:
+		return HandlerE(f)
+	case func(http.ResponseWriter, *http.Request, *Context) error:
+		return HandlerE(f)
+	case Handler:
+		return f.ServeHTTPContext
+	}
+	panic(fmt.Sprintf("patree: %T is not a handler", f))
+}
+
+// handlerFuncs returns the HandlerFuncs of the handlers.
+func handlerFuncs(f []interface{}) []HandlerFunc {
+	funcs := make([]HandlerFunc, len(f))
+	for i, h := range f {
+		funcs[i] = handlerFunc(h)
+	}
+	return funcs
+}
+
 // Context represents a context of http request.
 type Context struct {
 	route  *Route
 	Params map[string]string
 	Err    error
 	holdUp bool
+
+	// Trace records how patterns are matched with the request if it's set
+	// before the request is routed.
+	Trace *Trace
+
+	pattern     string
+	meta        Meta
+	writer      *StatusWriter
+	onMatch     []func(c *Context)
+	requestID   string
+	spanContext SpanContext
+
+	// hooks is the route whose NotFound, MethodNotAllowed and OnError
+	// handlers handle the request, and finished is true after one of them
+	// is called.
+	hooks    *Route
+	finished bool
 }
 
-// Next invoke next route with the given ResponseWriter and Request
+// Next invoke next route with the given ResponseWriter and Request. At the end
+// of the chain of a Route, it calls the NotFound or MethodNotAllowed handler
+// of the route with w, so that middlewares observe the response.
 func (c *Context) Next(w http.ResponseWriter, r *http.Request) {
 	if c.route == nil {
 		return
@@ -35,9 +85,21 @@ func (c *Context) Next(w http.ResponseWriter, r *http.Request) {
 
 	if next := c.route.next; next != nil {
 		c.route = next
-		next.ServeHTTPContext(w, r, c)
+		c.serve(next, w, r)
 	} else {
 		c.holdUp = true
+		if c.hooks != nil && c.route == c.hooks.getLeaf() {
+			c.hooks.finish(w, r, c)
+		}
+	}
+}
+
+// serve calls the handler of the route, and then the OnError handler if the
+// handler sets Err.
+func (c *Context) serve(route *Route, w http.ResponseWriter, r *http.Request) {
+	route.f.ServeHTTPContext(w, r, c)
+	if c.Err != nil && c.hooks != nil {
+		c.hooks.finish(w, r, c)
 	}
 }
 
@@ -47,28 +109,34 @@ func (c *Context) NotFound() bool {
 }
 
 type patternRouter struct {
-	entry *Entry
+	entry   *Entry
+	escaped bool
 }
 
-func newRouter() *patternRouter {
+func newRouter(matchers *MatcherRegistry, escaped bool) *patternRouter {
 	entry := newStaticEntry("")
 	entry.exec = entry.traverse
-	return &patternRouter{entry}
+	entry.matchers = matchers
+	return &patternRouter{entry, escaped}
 }
 
 func (p *patternRouter) ServeHTTPContext(w http.ResponseWriter, r *http.Request, c *Context) {
-	route, paramArray := p.entry.exec(r.Method, r.URL.Path)
+	route, params := p.match(r.Method, r.URL, c.Trace)
 	if route == nil {
 		c.Next(w, r)
 		return
 	}
 
 	// TODO hold old maps
-	c.Params = createParams(paramArray)
+	c.Params = params
+	c.pattern, c.meta = route.pattern, route.meta
+	for _, f := range c.onMatch {
+		f(c)
+	}
 
 	current := c.route
 	c.route = route
-	route.ServeHTTPContext(w, r, c)
+	c.serve(route, w, r)
 	c.route = current
 
 	if c.holdUp {
@@ -77,34 +145,191 @@ func (p *patternRouter) ServeHTTPContext(w http.ResponseWriter, r *http.Request,
 	}
 }
 
+// match returns the handler and params that match with the method and url.
+// Visited entries are recorded to the trace unless it's nil.
+func (p *patternRouter) match(method string, u *url.URL, t *Trace) (*Route, map[string]string) {
+	urlStr := p.path(u)
+
+	var route *Route
+	var paramArray []string
+	if t == nil {
+		route, paramArray = p.entry.exec(method, urlStr)
+	} else {
+		route, paramArray = p.entry.explain(t, 0, method, urlStr)
+	}
+	if route == nil {
+		return nil, nil
+	}
+
+	params := createParams(paramArray)
+	if p.escaped {
+		unescapeParams(params)
+	}
+	if t != nil {
+		t.Pattern, t.Params = route.pattern, params
+	}
+	return route, params
+}
+
+// path returns the path of the url that patterns are matched against.
+func (p *patternRouter) path(u *url.URL) string {
+	if p.escaped {
+		return normalizeEscapedPath(u.EscapedPath())
+	}
+	return u.Path
+}
+
+func (p *patternRouter) clone() *patternRouter {
+	entry := p.entry.clone()
+	entry.exec = entry.traverse
+	return &patternRouter{entry, p.escaped}
+}
+
 func (p *patternRouter) registerPattern(pat string) *Entry {
 	patterns, err := SplitPath(pat)
 	if err != nil {
 		panic(err)
 	}
+	if p.escaped {
+		for i, pat := range patterns {
+			if !isMatchPattern(pat) {
+				patterns[i] = escapePattern(pat)
+			}
+		}
+	}
 	return p.entry.MergePatterns(patterns)
 }
 
 // Route is a chainable handler
 type Route struct {
-	f    Handler
-	next *Route
+	f        Handler
+	next     *Route
+	matchers *MatcherRegistry
+	escaped  bool
+	pattern  string
+	meta     Meta
+
+	notFound         HandlerFunc
+	methodNotAllowed HandlerFunc
+	onError          ErrorHandlerFunc
+}
+
+// NewRoute returns a new Route that resolves match types of its patterns
+// through the given registry. A nil registry, as well as the zero value of
+// Route, resolves match types through MatcherMap.
+func NewRoute(matchers *MatcherRegistry) *Route {
+	return &Route{matchers: matchers}
+}
+
+// Matchers returns the MatcherRegistry of the route. It returns nil if the
+// route uses MatcherMap.
+func (r *Route) Matchers() *MatcherRegistry {
+	return r.matchers
+}
+
+// MatchEscapedPath makes the route match patterns against the escaped form of
+// request paths rather than the decoded r.URL.Path, so that an encoded slash
+// "%2F" doesn't split a path segment. Static patterns are compared in their
+// escaped form, and matched params are decoded. It panics if patterns are
+// already registered, since they're matched against decoded paths.
+func (r *Route) MatchEscapedPath() {
+	if len(r.Entries()) != 0 {
+		panic("MatchEscapedPath must be called before registering patterns")
+	}
+	r.escaped = true
+}
+
+// Match returns the pattern and params of the handlers that a request with the
+// method and url would be routed to. Patterns are looked up in the order they
+// are chained, regardless of middlewares between them. ok is false if no
+// pattern matches.
+func (r *Route) Match(method, urlStr string) (pattern string, params map[string]string, ok bool) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return
+	}
+	if h, params := r.lookup(method, u); h != nil {
+		return h.pattern, params, true
+	}
+	return
+}
+
+// lookup returns the handlers and params of the first pattern that matches
+// with the method and url.
+func (r *Route) lookup(method string, u *url.URL) (*Route, map[string]string) {
+	for route := r; route != nil; route = route.next {
+		p, isRouter := route.f.(*patternRouter)
+		if !isRouter {
+			continue
+		}
+		if h, params := p.match(method, u, nil); h != nil {
+			return h, params
+		}
+	}
+	return nil, nil
+}
+
+// AllowedMethods returns the sorted methods of the handlers whose patterns
+// match with the url. A handler that matches with any method is listed as
+// "*".
+func (r *Route) AllowedMethods(urlStr string) []string {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil
+	}
+	return r.allowedMethods(u)
+}
+
+func (r *Route) allowedMethods(u *url.URL) []string {
+	set := make(map[string]bool)
+	for route := r; route != nil; route = route.next {
+		if p, ok := route.f.(*patternRouter); ok {
+			p.entry.collectMethods(p.path(u), set)
+		}
+	}
+	return sortedMethods(set)
+}
+
+// Entries returns the root entries of patterns chained to the route.
+func (r *Route) Entries() []*Entry {
+	var entries []*Entry
+	for route := r; route != nil; route = route.next {
+		if p, ok := route.f.(*patternRouter); ok {
+			entries = append(entries, p.entry)
+		}
+	}
+	return entries
 }
 
 // ServeHTTP implement http.Handler interface
 func (route *Route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	c := &Context{route: route}
-	route.ServeHTTPContext(w, r, c)
+	route.ServeHTTPContext(w, r, &Context{})
 }
 
-// ServeHTTPContext implements Handler interface
+// ServeHTTPContext implements Handler interface, so that a Route can be
+// mounted to another Route. The request is served by the chain of the route,
+// and falls through to the handlers after the mounted route unless it's
+// handled. The NotFound, MethodNotAllowed and OnError handlers of the route,
+// if any, handle requests of its chain.
 func (route *Route) ServeHTTPContext(w http.ResponseWriter, r *http.Request, c *Context) {
-	route.f.ServeHTTPContext(w, r, c)
+	current, hooks := c.route, c.hooks
+	c.route = route
+	if route.hasHooks() {
+		c.hooks = route
+	}
+	c.serve(route, w, r)
+	c.route, c.hooks = current, hooks
+
+	if c.holdUp && !c.finished && current != nil {
+		c.holdUp = false
+		c.Next(w, r)
+	}
 }
 
-// Use appends a HandlerFunc to the route.
-func (r *Route) Use(f HandlerFunc) {
-	r.UseHandler(f)
+// Use appends a handler to the route. It's a HandlerFunc, a HandlerFuncE, a
+// function of either signature or a Handler.
+func (r *Route) Use(f interface{}) {
+	r.UseHandler(handlerFunc(f))
 }
 
 // UseHandler appends a Handler to the route.
@@ -139,7 +364,7 @@ func (r *Route) addPattern(pat string) *Entry {
 	}
 
 	if !isRouter {
-		p = newRouter()
+		p = newRouter(r.matchers, r.escaped)
 		defer r.UseHandler(p)
 	}
 
@@ -148,51 +373,65 @@ func (r *Route) addPattern(pat string) *Entry {
 }
 
 // HandleMethod registers handler funcs with the given pattern and method.
-func (r *Route) HandleMethod(pat, method string, f ...HandlerFunc) {
-	entry := r.addPattern(pat)
-	batch := batchRoute(f)
-	if err := entry.SetMethodHandler(method, batch); err != nil {
+// Handlers are of the types that Use takes.
+func (r *Route) HandleMethod(pat, method string, f ...interface{}) {
+	if err := r.handle(pat, method, handlerFuncs(f), nil); err != nil {
 		panic(err)
 	}
 }
 
 // Handle registers handler funcs with the given pattern.
-func (r *Route) Handle(pat string, f ...HandlerFunc) {
+func (r *Route) Handle(pat string, f ...interface{}) {
+	if err := r.handle(pat, "", handlerFuncs(f), nil); err != nil {
+		panic(err)
+	}
+}
+
+// handle registers handler funcs with the given pattern, method and metadata.
+// The handlers match with any method if the method is empty. It returns an
+// error rather than panics if the pattern is invalid or already registered.
+func (r *Route) handle(pat, method string, f []HandlerFunc, meta Meta) error {
+	if err := r.matchers.CheckPattern(pat); err != nil {
+		return err
+	}
 	entry := r.addPattern(pat)
 	batch := batchRoute(f)
-	if err := entry.SetHandler(batch); err != nil {
-		panic(err)
+	batch.pattern = pat
+	batch.meta = meta
+	if method == "" {
+		return entry.SetHandler(batch)
 	}
+	return entry.SetMethodHandler(method, batch)
 }
 
 // Get registers handlers with the given pattern for GET and HEAD method
-func (r *Route) Get(pat string, f ...HandlerFunc) {
+func (r *Route) Get(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "GET", f...)
 	r.HandleMethod(pat, "HEAD", f...)
 }
 
 // Post registers handlers with the given pattern for POST method
-func (r *Route) Post(pat string, f ...HandlerFunc) {
+func (r *Route) Post(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "POST", f...)
 }
 
 // Put registers handlers with the given pattern for PUT method
-func (r *Route) Put(pat string, f ...HandlerFunc) {
+func (r *Route) Put(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "PUT", f...)
 }
 
 // Patch registers handlers with the given pattern for PATCH method
-func (r *Route) Patch(pat string, f ...HandlerFunc) {
+func (r *Route) Patch(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "PATCH", f...)
 }
 
 // Delete registers handlers with the given pattern for DELETE method
-func (r *Route) Delete(pat string, f ...HandlerFunc) {
+func (r *Route) Delete(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "DELETE", f...)
 }
 
 // Options registers handlers with the given pattern for OPTIONS method
-func (r *Route) Options(pat string, f ...HandlerFunc) {
+func (r *Route) Options(pat string, f ...interface{}) {
 	r.HandleMethod(pat, "OPTIONS", f...)
 }
 
@@ -205,6 +444,74 @@ func createParams(paramArray []string) map[string]string {
 	return p
 }
 
+// escapePattern returns the escaped form of the static pattern that can be
+// compared with normalized escaped paths.
+func escapePattern(pat string) string {
+	u := &url.URL{Path: pat}
+	return normalizeEscapedPath(u.EscapedPath())
+}
+
+// normalizeEscapedPath decodes percent-encoded unreserved characters and
+// upper cases hex digits of the other percent-encodings.
+func normalizeEscapedPath(s string) string {
+	var b []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) || !isHex(rune(s[i+1])) ||
+			!isHex(rune(s[i+2])) {
+			if b != nil {
+				b = append(b, s[i])
+			}
+			continue
+		}
+		if b == nil {
+			b = append(make([]byte, 0, len(s)), s[:i]...)
+		}
+		c := unhex(s[i+1])<<4 | unhex(s[i+2])
+		if isUnreserved(c) {
+			b = append(b, c)
+		} else {
+			b = append(b, '%', upperHex(s[i+1]), upperHex(s[i+2]))
+		}
+		i += 2
+	}
+	if b == nil {
+		return s
+	}
+	return string(b)
+}
+
+// isUnreserved see if the byte is an unreserved character of RFC 3986.
+func isUnreserved(c byte) bool {
+	return isBase62(rune(c)) || c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case isDigit(rune(c)):
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
+
+func upperHex(c byte) byte {
+	if 'a' <= c && c <= 'f' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
+
+// unescapeParams decodes percent-encoded param values. A value that can't be
+// decoded is left as it is.
+func unescapeParams(p map[string]string) {
+	for k, v := range p {
+		if s, err := url.PathUnescape(v); err == nil {
+			p[k] = s
+		}
+	}
+}
+
 func batchRoute(f []HandlerFunc) *Route {
 	batch := &Route{}
 	for _, h := range f {
@@ -184,6 +184,24 @@ func TestMuxer(t *testing.T) {
 			params{"date_start": "2014-01-01", "date_end": "2014-12-31"}},
 		{"/date-<date:date>", "/date-2050-10-09", params{"date": "2050-10-09"}},
 		{"<date:d>", "0010-05-30", params{"d": "0010-05-30"}},
+		{"/releases/<semver:v>", "/releases/1.0.0-rc.1",
+			params{"v": "1.0.0-rc.1"}},
+		{"/releases/<semver:v>.tar.gz", "/releases/1.2.3.tar.gz",
+			params{"v": "1.2.3"}},
+		{"/objects/<objectid:id>/<ulid:rev>",
+			"/objects/507f1f77bcf86cd799439011/01ARZ3NDEKTSV4RRFFQ69G5FAV",
+			params{"id": "507f1f77bcf86cd799439011",
+				"rev": "01ARZ3NDEKTSV4RRFFQ69G5FAV"}},
+		{"/uuid4/<uuid4:id>", "/uuid4/BE567C9C-6392-4F6D-B5AE-E35893F956BB",
+			params{"id": "be567c9c-6392-4f6d-b5ae-e35893f956bb"}},
+		{"/uuid4/<uuid4:id>abcdef",
+			"/uuid4/be567c9c-6392-4f6d-b5ae-e35893f956bbabcdef",
+			params{"id": "be567c9c-6392-4f6d-b5ae-e35893f956bb"}},
+		{"/compact/<uuid_compact:id>",
+			"/compact/BE567C9C63924F6DB5AEE35893F956BB",
+			params{"id": "be567c9c-6392-4f6d-b5ae-e35893f956bb"}},
+		{"/tweets/<snowflake:id>", "/tweets/1541815603606036480",
+			params{"id": "1541815603606036480"}},
 	}
 
 	execTests(m, cases, t)
@@ -241,3 +259,98 @@ func TestMethodMap(t *testing.T) {
 		t.Fatal("Missed executing a handler. Count should be 0 instead of", count)
 	}
 }
+
+func TestEscapedPath(t *testing.T) {
+	mux := &Route{}
+	mux.MatchEscapedPath()
+
+	cases := []routeTestCase{
+		{"/files/<name>", "/files/a%2Fb", params{"name": "a/b"}},
+		{"/files/<name>/raw", "/files/a%2fb%20c/raw", params{"name": "a/b c"}},
+		{"/hello world/<int:id>", "/hello%20world/12", params{"id": "12"}},
+		{"/~user/<name>", "/%7Euser/%E3%81%82", params{"name": "あ"}},
+		{"/日本/<name>", "/日本/語", params{"name": "語"}},
+		{"/日本/<name>.txt", "/%E6%97%A5%E6%9C%AC/%e8%aa%9e.txt",
+			params{"name": "語"}},
+		{"/tags/<tag>-<int:id>", "/tags/c%2B%2B-10",
+			params{"tag": "c++", "id": "10"}},
+	}
+	execTests(mux, cases, t)
+
+	// decoded paths split segments by encoded slashes
+	mux = &Route{}
+	mux.Handle("/files/<name>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		t.Fatalf("%s should not be matched", r.URL)
+	})
+	r, err := http.NewRequest("GET", "/files/a%2Fb", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.ServeHTTP(nil, r)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("MatchEscapedPath should panic after registering patterns")
+		}
+	}()
+	mux.MatchEscapedPath()
+}
+
+func TestNormalizeEscapedPath(t *testing.T) {
+	cases := map[string]string{
+		"/foo/bar":           "/foo/bar",
+		"/foo%2fbar":         "/foo%2Fbar",
+		"/%7Euser/%41%2d":    "/~user/A-",
+		"/%e6%97%a5":         "/%E6%97%A5",
+		"/100%":              "/100%",
+		"/100%2":             "/100%2",
+		"/%zz%20":            "/%zz%20",
+		"/a%20b%2Fc%3a%3A/d": "/a%20b%2Fc%3A%3A/d",
+	}
+	for s, expected := range cases {
+		if ret := normalizeEscapedPath(s); ret != expected {
+			t.Fatalf("Got %s instead of expected %s with input %s", ret,
+				expected, s)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	mux := &Route{}
+	mux.Get("/posts/<int:id>", foobar)
+	mux.Use(foobar)
+	mux.Handle("/posts/<slug>", foobar)
+
+	cases := []struct {
+		method  string
+		urlStr  string
+		pattern string
+		params  params
+	}{
+		{"GET", "/posts/10", "/posts/<int:id>", params{"id": "10"}},
+		{"POST", "/posts/10", "/posts/<slug>", params{"slug": "10"}},
+		{"GET", "/posts/golang?page=2", "/posts/<slug>", params{"slug": "golang"}},
+		{"GET", "/posts", "", nil},
+	}
+	for _, tc := range cases {
+		pat, p, ok := mux.Match(tc.method, tc.urlStr)
+		if ok != (tc.pattern != "") || pat != tc.pattern {
+			t.Fatalf("%s %s should match %s. Got %s instead", tc.method,
+				tc.urlStr, tc.pattern, pat)
+		}
+		if len(p) != len(tc.params) {
+			t.Fatalf("%s %s should have params %v. Got %v instead", tc.method,
+				tc.urlStr, tc.params, p)
+		}
+		for k, v := range tc.params {
+			if p[k] != v {
+				t.Fatalf("%s %s should have params %v. Got %v instead",
+					tc.method, tc.urlStr, tc.params, p)
+			}
+		}
+	}
+
+	if n := len(mux.Entries()); n != 2 {
+		t.Fatalf("route should have 2 root entries. Got %d instead", n)
+	}
+}
@@ -0,0 +1,227 @@
+// Package patreetest provides helpers for testing routes of patree. Routes are
+// identified by their registered patterns, e.g. "/posts/<int:id>". Requests
+// are served with httptest, so no server is needed.
+package patreetest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"testing"
+
+	"github.com/smagch/patree"
+)
+
+// Case is a routing assertion. A request with Method and Path should be
+// routed to the handlers of Pattern with Params. Pattern is empty if no
+// pattern should match.
+type Case struct {
+	Method  string
+	Path    string
+	Pattern string
+	Params  map[string]string
+
+	// Status is the expected status code of the response. The request is
+	// served only if it's set.
+	Status int
+}
+
+// Run asserts every case against the route.
+func Run(t testing.TB, mux *patree.Route, cases []Case) {
+	t.Helper()
+	for _, tc := range cases {
+		pat, params, _ := mux.Match(tc.Method, tc.Path)
+		if pat != tc.Pattern {
+			if tc.Pattern == "" {
+				t.Fatalf("%s %s should not match. Got %s instead", tc.Method,
+					tc.Path, pat)
+			}
+			t.Fatalf("%s %s should match %s. Got %q instead", tc.Method,
+				tc.Path, tc.Pattern, pat)
+		}
+		if !equalParams(params, tc.Params) {
+			t.Fatalf("%s %s should have params %v. Got %v instead", tc.Method,
+				tc.Path, tc.Params, params)
+		}
+
+		if tc.Status == 0 {
+			continue
+		}
+		if w := Serve(mux, tc.Method, tc.Path); w.Code != tc.Status {
+			t.Fatalf("%s %s should respond %d. Got %d instead", tc.Method,
+				tc.Path, tc.Status, w.Code)
+		}
+	}
+}
+
+// Serve serves a request with the method and path, and returns the recorded
+// response.
+func Serve(h http.Handler, method, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+	return w
+}
+
+// AssertRoute asserts that a request with the method and path is routed to
+// the pattern with the params.
+func AssertRoute(t testing.TB, mux *patree.Route, method, path, pattern string, params map[string]string) {
+	t.Helper()
+	Run(t, mux, []Case{{Method: method, Path: path, Pattern: pattern,
+		Params: params}})
+}
+
+// AssertNotFound asserts that no pattern matches the path with any method,
+// and that the route responds 404.
+func AssertNotFound(t testing.TB, mux *patree.Route, method, path string) {
+	t.Helper()
+	if allowed := mux.AllowedMethods(path); len(allowed) != 0 {
+		t.Fatalf("%s should not match. Got methods %v instead", path, allowed)
+	}
+	Run(t, mux, []Case{{Method: method, Path: path,
+		Status: http.StatusNotFound}})
+}
+
+// AssertMethodNotAllowed asserts that no pattern matches the path with the
+// method, that the path matches with exactly the allowed methods in any
+// order, and that the route responds 405. Handlers that match with any method
+// are allowed as "*", as in patree.Route.AllowedMethods.
+func AssertMethodNotAllowed(t testing.TB, mux *patree.Route, method, path string, allowed ...string) {
+	t.Helper()
+	if got := mux.AllowedMethods(path); !equalMethods(got, allowed) {
+		t.Fatalf("%s should allow %v. Got %v instead", path, allowed, got)
+	}
+	Run(t, mux, []Case{{Method: method, Path: path,
+		Status: http.StatusMethodNotAllowed}})
+}
+
+// Patterns returns the patterns of the route declarations keyed by their
+// names, or handler names if they have no names, so that cases can refer to
+// named routes.
+func Patterns(specs []patree.RouteSpec) map[string]string {
+	patterns := make(map[string]string)
+	for _, spec := range specs {
+		name := spec.Name
+		if name == "" {
+			name = spec.Handler
+		}
+		patterns[name] = spec.Pattern
+	}
+	return patterns
+}
+
+// Conflict returns an example url that both patterns match. ok is false if
+// no example url of either pattern matches the other.
+func Conflict(a, b string) (urlStr string, ok bool) {
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		mux := &patree.Route{}
+		noop := func(w http.ResponseWriter, r *http.Request, c *patree.Context) {}
+		mux.Handle(pair[1], noop)
+		for _, urlStr := range patree.ExampleURLs(pair[0]) {
+			if _, _, ok := mux.Match("GET", urlStr); ok {
+				return urlStr, true
+			}
+		}
+	}
+	return "", false
+}
+
+// AssertConflict asserts that there is an url that both patterns match.
+func AssertConflict(t testing.TB, a, b string) {
+	t.Helper()
+	if _, ok := Conflict(a, b); !ok {
+		t.Fatalf("%s and %s should conflict", a, b)
+	}
+}
+
+// SkipValue is the error returned by URL when a param value doesn't match
+// with its match type by itself, e.g. "foo" for "<int:id>".
+var SkipValue = errors.New("param value doesn't match with its match type")
+
+// URL returns the url of the pattern with the params, which is the reverse
+// routing of the pattern. Static patterns and params are escaped. Params are
+// checked by the matchers of the registry, which may be nil.
+func URL(matchers *patree.MatcherRegistry, pat string, params map[string]string) (string, error) {
+	patterns, err := patree.SplitPath(pat)
+	if err != nil {
+		return "", err
+	}
+
+	var urlStr string
+	for _, p := range patterns {
+		matchType, name, ok := patree.ParseMatchPattern(p)
+		if !ok {
+			u := &url.URL{Path: p}
+			urlStr += u.EscapedPath()
+			continue
+		}
+
+		v, ok := params[name]
+		if !ok {
+			return "", errors.New("no param: " + name)
+		}
+		m := matchers.Lookup(matchType)
+		if m == nil {
+			return "", errors.New("no such match type: " + matchType)
+		}
+		if offset, matchStr := m.Match(v); offset != len(v) || matchStr != v {
+			return "", SkipValue
+		}
+		urlStr += url.PathEscape(v)
+	}
+	return urlStr, nil
+}
+
+// RoundTrip asserts that the url of the pattern with the params is routed
+// back to the pattern with the same params. It's meant to be called from
+// fuzz targets, e.g.
+//
+//	f.Fuzz(func(t *testing.T, id int64, slug string) {
+//		patreetest.RoundTrip(t, mux, "GET", "/posts/<int:id>/<slug>",
+//			map[string]string{"id": strconv.FormatInt(id, 10), "slug": slug})
+//	})
+//
+// The test is skipped if a param value doesn't match with its match type.
+func RoundTrip(t testing.TB, mux *patree.Route, method, pattern string, params map[string]string) {
+	t.Helper()
+	urlStr, err := URL(mux.Matchers(), pattern, params)
+	if err == SkipValue {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	AssertRoute(t, mux, method, urlStr, pattern, params)
+}
+
+// equalParams see if the params have the same values. A nil map is equal to
+// an empty map.
+func equalParams(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || v != w {
+			return false
+		}
+	}
+	return true
+}
+
+// equalMethods see if the methods are the same regardless of their order.
+func equalMethods(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
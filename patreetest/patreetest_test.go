@@ -0,0 +1,109 @@
+package patreetest
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/smagch/patree"
+)
+
+func noop(w http.ResponseWriter, r *http.Request, c *patree.Context) {}
+
+func newRoute() *patree.Route {
+	mux := &patree.Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *patree.Context) {
+		c.Next(w, r)
+		if !c.NotFound() {
+			return
+		}
+		if len(mux.AllowedMethods(r.URL.Path)) != 0 {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		http.NotFound(w, r)
+	})
+	mux.Get("/posts/<int:id>", noop)
+	mux.Delete("/posts/<int:id>", noop)
+	mux.Get("/posts/<int:id>/<slug>", noop)
+	mux.Get("/users/<uuid:id>", noop)
+	return mux
+}
+
+func TestRun(t *testing.T) {
+	routes := Patterns([]patree.RouteSpec{
+		{Name: "post", Pattern: "/posts/<int:id>"},
+		{Handler: "user", Pattern: "/users/<uuid:id>"},
+	})
+	mux := newRoute()
+	Run(t, mux, []Case{
+		{Method: "GET", Path: "/posts/10", Pattern: routes["post"],
+			Params: map[string]string{"id": "10"}, Status: http.StatusOK},
+		{Method: "GET", Path: "/users/be567c9c-6392-4f6d-b5ae-e35893f956bb",
+			Pattern: routes["user"],
+			Params:  map[string]string{"id": "be567c9c-6392-4f6d-b5ae-e35893f956bb"}},
+		{Method: "GET", Path: "/posts/foo", Status: http.StatusNotFound},
+	})
+	AssertRoute(t, mux, "GET", "/posts/10/golang", "/posts/<int:id>/<slug>",
+		map[string]string{"id": "10", "slug": "golang"})
+	AssertNotFound(t, mux, "GET", "/users/10")
+	AssertMethodNotAllowed(t, mux, "POST", "/posts/10", "HEAD", "GET", "DELETE")
+}
+
+func TestConflict(t *testing.T) {
+	cases := []struct {
+		a, b   string
+		urlStr string
+	}{
+		{"/posts/<int:id>", "/posts/<slug>", "/posts/1"},
+		{"/posts/<slug>", "/posts/2014", "/posts/2014"},
+		{"/posts/<id>.html", "/posts/<slug>", "/posts/x.html"},
+		{"/posts/<int:id>", "/posts/<uuid:id>", ""},
+		{"/posts/<int:id>", "/users/<int:id>", ""},
+	}
+	for _, tc := range cases {
+		urlStr, ok := Conflict(tc.a, tc.b)
+		if urlStr != tc.urlStr || ok != (tc.urlStr != "") {
+			t.Fatalf("%s and %s should conflict with %q. Got %q instead", tc.a,
+				tc.b, tc.urlStr, urlStr)
+		}
+	}
+	AssertConflict(t, "/<id>", "/<default:id>")
+}
+
+func TestURL(t *testing.T) {
+	cases := []struct {
+		pattern string
+		params  map[string]string
+		urlStr  string
+		err     error
+	}{
+		{"/posts/<int:id>/<slug>", map[string]string{"id": "1", "slug": "a b"},
+			"/posts/1/a%20b", nil},
+		{"/posts/<int:id>", map[string]string{"id": "foo"}, "", SkipValue},
+		{"/posts/<slug>", map[string]string{"slug": "a/b"}, "", SkipValue},
+	}
+	for _, tc := range cases {
+		urlStr, err := URL(nil, tc.pattern, tc.params)
+		if urlStr != tc.urlStr || err != tc.err {
+			t.Fatalf("%s should be %q, %v. Got %q, %v instead", tc.pattern,
+				tc.urlStr, tc.err, urlStr, err)
+		}
+	}
+	if _, err := URL(nil, "/posts/<int:id>", nil); err == nil {
+		t.Fatal("missing params should be an error")
+	}
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	mux := newRoute()
+	f.Add(int64(10), "golang")
+	f.Add(int64(-1), "%2F")
+	f.Add(int64(0), "日本語")
+	f.Fuzz(func(t *testing.T, id int64, slug string) {
+		RoundTrip(t, mux, "GET", "/posts/<int:id>/<slug>", map[string]string{
+			"id":   strconv.FormatInt(id, 10),
+			"slug": slug,
+		})
+	})
+}
@@ -0,0 +1,268 @@
+package patree
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitMetaKey is the key of the route metadata that has the RateLimit of
+// the route.
+const RateLimitMetaKey = "ratelimit"
+
+// RateLimit is a token bucket rate limit of a route, e.g. 10 requests per
+// second per user_id:
+//
+//	r.WithMeta(patree.Meta{patree.RateLimitMetaKey: patree.RateLimit{
+//		Rate: 10, Params: []string{"user_id"},
+//	}}).With(limiter.Limit).Post("/users/<int:user_id>/messages", post)
+//
+// The metadata of route files is decoded as a RateLimit, e.g.
+// {"ratelimit": {"rate": 10, "params": ["user_id"]}}.
+type RateLimit struct {
+	// Rate is the number of tokens added to a bucket per second.
+	Rate float64 `json:"rate"`
+
+	// Burst is the capacity of a bucket. It's Rate rounded up if zero.
+	Burst int `json:"burst"`
+
+	// Params are the names of params that buckets are keyed by in addition to
+	// the matched pattern.
+	Params []string `json:"params"`
+
+	// IP keys buckets by the client IP as well. Buckets are keyed by the
+	// client IP if Params is empty.
+	IP bool `json:"ip"`
+}
+
+// burst returns the capacity of a bucket.
+func (l RateLimit) burst() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.Rate)))
+}
+
+// RateLimitResult is the result of taking a token from a bucket.
+type RateLimitResult struct {
+	// Allowed is true if a token is taken.
+	Allowed bool
+
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+
+	// RetryAfter is the duration until a token is available if the token
+	// isn't taken.
+	RetryAfter time.Duration
+
+	// Reset is the duration until the bucket is full.
+	Reset time.Duration
+}
+
+// RateLimitStore stores token buckets. Implement it to share buckets between
+// servers, e.g. with Redis.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of the key at the time.
+	Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error)
+}
+
+// MemoryRateLimitStore is a RateLimitStore that stores buckets in memory. The
+// zero value is ready to use.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	sweepAt int
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	limit  RateLimit
+}
+
+// Take takes a token from the bucket of the key. Buckets that are full are
+// removed as the number of buckets grows.
+func (s *MemoryRateLimitStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets == nil {
+		s.buckets = make(map[string]*tokenBucket)
+	}
+
+	burst := float64(limit.burst())
+	b := s.buckets[key]
+	if b == nil {
+		s.sweep(now)
+		b = &tokenBucket{burst, now, limit}
+		s.buckets[key] = b
+	}
+	b.limit = limit
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+
+	var res RateLimitResult
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = rateDuration(1-b.tokens, limit.Rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = rateDuration(burst-b.tokens, limit.Rate)
+	return res, nil
+}
+
+// sweep removes buckets that are full if the number of buckets reaches the
+// threshold, which doubles the number of remaining buckets.
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if len(s.buckets) < s.sweepAt {
+		return
+	}
+	for key, b := range s.buckets {
+		elapsed := now.Sub(b.last).Seconds()
+		if b.tokens+elapsed*b.limit.Rate >= float64(b.limit.burst()) {
+			delete(s.buckets, key)
+		}
+	}
+	s.sweepAt = 2 * len(s.buckets)
+	if s.sweepAt < 1024 {
+		s.sweepAt = 1024
+	}
+}
+
+// rateDuration returns the duration to add the tokens at the rate.
+func rateDuration(tokens, rate float64) time.Duration {
+	if rate <= 0 {
+		return 0
+	}
+	return time.Duration(tokens / rate * float64(time.Second))
+}
+
+// RateLimiter limits request rates of routes that have the RateLimit of
+// RateLimitMetaKey in their metadata. The zero value is ready to use.
+type RateLimiter struct {
+	// Store stores buckets. A MemoryRateLimitStore is used if it's nil.
+	Store RateLimitStore
+
+	// ClientIP returns the client IP of the request. It's the host of
+	// Request.RemoteAddr if nil. Set it to read a header of a trusted proxy.
+	ClientIP func(r *http.Request) string
+
+	// Exceeded handles requests that exceed their rate limits. It responds
+	// 429 with the text of the status if nil.
+	Exceeded HandlerFunc
+
+	once sync.Once
+	now  func() time.Time
+}
+
+// Limit is the middleware that takes a token from the bucket of the request.
+// Since limits are read from the metadata of the matched route, use it as a
+// group middleware, e.g. r.With(limiter.Limit). Responses have RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers, and requests that exceed
+// their limits are handled by Exceeded with a Retry-After header. Errors of
+// the store are set to Context.Err, and the request isn't served.
+func (l *RateLimiter) Limit(w http.ResponseWriter, r *http.Request, c *Context) {
+	limit, ok, err := rateLimitOf(c.RouteMeta())
+	if err != nil {
+		c.Err = err
+		return
+	}
+	if !ok {
+		c.Next(w, r)
+		return
+	}
+
+	l.once.Do(func() {
+		if l.Store == nil {
+			l.Store = &MemoryRateLimitStore{}
+		}
+		if l.now == nil {
+			l.now = time.Now
+		}
+	})
+	res, err := l.Store.Take(l.key(r, c, limit), limit, l.now())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(limit.burst()))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+	if res.Allowed {
+		c.Next(w, r)
+		return
+	}
+
+	h.Set("Retry-After", ceilSeconds(res.RetryAfter))
+	if l.Exceeded != nil {
+		l.Exceeded(w, r, c)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusTooManyRequests),
+		http.StatusTooManyRequests)
+}
+
+// key returns the bucket key of the request, e.g.
+// "/users/<int:user_id>/messages?user_id=10".
+func (l *RateLimiter) key(r *http.Request, c *Context, limit RateLimit) string {
+	values := make(url.Values, len(limit.Params)+1)
+	for _, name := range limit.Params {
+		values.Set(name, c.Params[name])
+	}
+	if limit.IP || len(limit.Params) == 0 {
+		values.Set("ip", l.clientIP(r))
+	}
+	return c.Pattern() + "?" + values.Encode()
+}
+
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.ClientIP != nil {
+		return l.ClientIP(r)
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// rateLimitOf returns the RateLimit of the metadata. The value of a route file
+// is decoded from JSON.
+func rateLimitOf(meta Meta) (limit RateLimit, ok bool, err error) {
+	switch v := meta[RateLimitMetaKey].(type) {
+	case nil:
+		return limit, false, nil
+	case RateLimit:
+		limit = v
+	case *RateLimit:
+		limit = *v
+	default:
+		b, err := json.Marshal(v)
+		if err == nil {
+			err = json.Unmarshal(b, &limit)
+		}
+		if err != nil {
+			return limit, false, errors.New("invalid rate limit: " + err.Error())
+		}
+	}
+	if limit.Rate <= 0 {
+		return limit, false, errors.New("invalid rate limit: rate must be positive")
+	}
+	return limit, true, nil
+}
+
+// ceilSeconds returns the duration in seconds rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
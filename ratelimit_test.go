@@ -0,0 +1,86 @@
+package patree
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := &RateLimiter{now: func() time.Time { return now }}
+	mux := &Route{}
+	mux.WithMeta(Meta{RateLimitMetaKey: RateLimit{Rate: 1, Burst: 2,
+		Params: []string{"user_id"}}}).With(limiter.Limit).
+		Post("/users/<int:user_id>/messages", featureHandler("message"))
+	mux.WithMeta(Meta{RateLimitMetaKey: map[string]interface{}{"rate": 0.5}}).
+		With(limiter.Limit).Get("/search", featureHandler("search"))
+	mux.With(limiter.Limit).Get("/posts", featureHandler("posts"))
+
+	cases := []struct {
+		method     string
+		urlStr     string
+		remoteAddr string
+		elapsed    time.Duration
+		code       int
+		remaining  string
+		retryAfter string
+	}{
+		{"POST", "/users/1/messages", "10.0.0.1:1", 0, 200, "1", ""},
+		{"POST", "/users/1/messages", "10.0.0.2:1", 0, 200, "0", ""},
+		{"POST", "/users/1/messages", "10.0.0.1:1", 0, 429, "0", "1"},
+		{"POST", "/users/2/messages", "10.0.0.1:1", 0, 200, "1", ""},
+		{"POST", "/users/1/messages", "10.0.0.1:1", 500 * time.Millisecond, 429, "0", "1"},
+		{"POST", "/users/1/messages", "10.0.0.1:1", 500 * time.Millisecond, 200, "0", ""},
+		{"GET", "/search", "10.0.0.1:1", 0, 200, "0", ""},
+		{"GET", "/search", "10.0.0.1:1", 0, 429, "0", "2"},
+		{"GET", "/search", "10.0.0.2:1", 0, 200, "0", ""},
+		{"GET", "/posts", "10.0.0.1:1", 0, 200, "", ""},
+		{"GET", "/posts", "10.0.0.1:1", 0, 200, "", ""},
+	}
+	for _, tc := range cases {
+		now = now.Add(tc.elapsed)
+		r := httptest.NewRequest(tc.method, tc.urlStr, nil)
+		r.RemoteAddr = tc.remoteAddr
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+		h := w.Header()
+		if w.Code != tc.code || h.Get("RateLimit-Remaining") != tc.remaining ||
+			h.Get("Retry-After") != tc.retryAfter {
+			t.Fatalf("%s %s from %s should respond %d with remaining %q and "+
+				"retry after %q. Got %d %q %q instead", tc.method, tc.urlStr,
+				tc.remoteAddr, tc.code, tc.remaining, tc.retryAfter, w.Code,
+				h.Get("RateLimit-Remaining"), h.Get("Retry-After"))
+		}
+	}
+}
+
+type failingStore struct{}
+
+func (failingStore) Take(key string, limit RateLimit, now time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store is down")
+}
+
+func TestRateLimiterErrors(t *testing.T) {
+	limiter := &RateLimiter{Store: failingStore{}}
+	mux := &Route{}
+	mux.WithMeta(Meta{RateLimitMetaKey: RateLimit{Rate: 1}}).
+		With(limiter.Limit).Get("/down", featureHandler("down"))
+	mux.WithMeta(Meta{RateLimitMetaKey: map[string]interface{}{"rate": "1"}}).
+		With(limiter.Limit).Get("/invalid", featureHandler("invalid"))
+	var errs []error
+	mux.OnError(func(w http.ResponseWriter, r *http.Request, c *Context, err error) {
+		errs = append(errs, err)
+	})
+
+	for _, urlStr := range []string{"/down", "/invalid"} {
+		if w := serve(mux, urlStr); w.Body.String() != "" {
+			t.Fatalf("%s should not be served. Got %q", urlStr, w.Body.String())
+		}
+	}
+	if len(errs) != 2 {
+		t.Fatalf("errors should be handled. Got %v instead", errs)
+	}
+}
@@ -0,0 +1,69 @@
+package patree
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is the error of a panic that Recover recovers.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value interface{}
+
+	// Pattern and Params are the matched pattern and params of the request.
+	// Pattern is empty if the panic occurs before a pattern matches.
+	Pattern string
+	Params  map[string]string
+
+	// Stack is the stack trace of the goroutine where the panic occurs.
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the value passed to panic if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// Recover returns a middleware that recovers panics of the following handlers.
+// The recovered panic is set to Context.Err as a PanicError, and passed to the
+// report function, which may be nil, e.g. to send it to an error tracker. The
+// middleware responds 500 only if nothing has been written yet, so that the
+// response is never corrupted. Use it before any other handler to recover
+// panics anywhere in the chain. A panic of http.ErrAbortHandler isn't
+// recovered.
+func Recover(report func(r *http.Request, err *PanicError)) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, c *Context) {
+		sw := c.StatusWriter(w)
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			err := &PanicError{
+				Value:   v,
+				Pattern: c.pattern,
+				Params:  c.Params,
+				Stack:   debug.Stack(),
+			}
+			c.Err = err
+			if report != nil {
+				report(r, err)
+			}
+			if !sw.Written() {
+				http.Error(sw, http.StatusText(http.StatusInternalServerError),
+					http.StatusInternalServerError)
+			}
+		}()
+		c.Next(sw, r)
+	}
+}
@@ -0,0 +1,109 @@
+package patree
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var reported *PanicError
+	mux := &Route{}
+	mux.Use(Recover(func(r *http.Request, err *PanicError) {
+		reported = err
+	}))
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		if r.URL.Path == "/middleware" {
+			panic("middleware")
+		}
+		c.Next(w, r)
+	})
+	mux.Get("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		panic(errors.New("post"))
+	})
+	mux.Get("/written", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		io.WriteString(w, "partial")
+		panic("written")
+	})
+	mux.OnError(RenderError)
+
+	cases := []struct {
+		urlStr  string
+		code    int
+		body    string
+		pattern string
+		value   string
+	}{
+		{"/posts/10", 500, "Internal Server Error\n", "/posts/<int:id>", "post"},
+		{"/written", 200, "partial", "/written", "written"},
+		{"/middleware", 500, "Internal Server Error\n", "", "middleware"},
+	}
+	for _, tc := range cases {
+		reported = nil
+		w := serve(mux, tc.urlStr)
+		if w.Code != tc.code || w.Body.String() != tc.body {
+			t.Fatalf("%s should respond %d %q. Got %d %q instead", tc.urlStr,
+				tc.code, tc.body, w.Code, w.Body.String())
+		}
+		if reported == nil || reported.Pattern != tc.pattern ||
+			reported.Error() != "panic: "+tc.value {
+			t.Fatalf("%s should report the panic of %s. Got %v instead",
+				tc.urlStr, tc.pattern, reported)
+		}
+		if !strings.Contains(string(reported.Stack), "recover_test.go") {
+			t.Fatalf("%s should report the stack. Got %s instead", tc.urlStr,
+				reported.Stack)
+		}
+	}
+	if id := reported.Params; id != nil {
+		t.Fatalf("the panic before matching should have no params. Got %v", id)
+	}
+
+	w := serve(mux, "/posts/10")
+	if reported.Params["id"] != "10" || reported.Unwrap().Error() != "post" {
+		t.Fatalf("the panic should report params and the error. Got %v, %v",
+			reported.Params, reported.Unwrap())
+	}
+	if w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Fatalf("RenderError should not write after Recover. Got %s",
+			w.Header().Get("Content-Type"))
+	}
+}
+
+func TestRecoverAbortHandler(t *testing.T) {
+	mux := &Route{}
+	mux.Use(Recover(nil))
+	mux.Get("/abort", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if v := recover(); v != http.ErrAbortHandler {
+			t.Fatalf("http.ErrAbortHandler should not be recovered. Got %v", v)
+		}
+	}()
+	serve(mux, "/abort")
+}
+
+func TestStatusWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	c := &Context{}
+	w := c.StatusWriter(rec)
+	if c.StatusWriter(w) != w || c.Written() {
+		t.Fatal("StatusWriter should be shared and not written")
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	io.WriteString(w, "created")
+	w.Flush()
+	if w.Status != 201 || w.Bytes != 7 || !c.Written() || !rec.Flushed {
+		t.Fatalf("StatusWriter should record 201 and 7 bytes. Got %d and %d",
+			w.Status, w.Bytes)
+	}
+	if _, _, err := w.Hijack(); err == nil {
+		t.Fatal("Hijack should fail with ResponseRecorder")
+	}
+}
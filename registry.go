@@ -0,0 +1,76 @@
+package patree
+
+import (
+	"errors"
+	"sync"
+)
+
+// DuplicateMatchType is the error returned by MatcherRegistry.Register when
+// the match type is already registered.
+var DuplicateMatchType = errors.New("Duplicate match type registration")
+
+// InvalidMatchType is the error returned by MatcherRegistry.Register when the
+// match type name can't be used in a pattern.
+var InvalidMatchType = errors.New("Invalid match type: name must consist of letters, digits and '_'")
+
+// NilMatcher is the error returned by MatcherRegistry.Register when the
+// matcher is nil.
+var NilMatcher = errors.New("Matcher must not be nil")
+
+// MatcherRegistry stores Matchers with match type keys. Unlike MatcherMap, a
+// registry can be scoped to a Route and is safe for concurrent use.
+type MatcherRegistry struct {
+	mu       sync.RWMutex
+	matchers map[string]Matcher
+}
+
+// NewMatcherRegistry returns a registry that has the built-in Matchers.
+func NewMatcherRegistry() *MatcherRegistry {
+	return &MatcherRegistry{matchers: newMatcherMap()}
+}
+
+// Register registers the matcher with the match type. It returns an error if
+// the match type is already registered or isn't a valid name.
+func (m *MatcherRegistry) Register(matchType string, matcher Matcher) error {
+	if !isMatchTypeName(matchType) {
+		return InvalidMatchType
+	}
+	if matcher == nil {
+		return NilMatcher
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.matchers[matchType]; ok {
+		return DuplicateMatchType
+	}
+	m.matchers[matchType] = matcher
+	return nil
+}
+
+// Lookup returns the Matcher of the match type, or nil if there is no such
+// match type. A nil registry looks up MatcherMap.
+func (m *MatcherRegistry) Lookup(matchType string) Matcher {
+	if m == nil {
+		return MatcherMap[matchType]
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.matchers[matchType]
+}
+
+// isMatchTypeName see if the given string only consists of ASCII letters,
+// digits and '_'.
+func isMatchTypeName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '_' && !isDigit(rune(c)) && !('a' <= c && c <= 'z') &&
+			!('A' <= c && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
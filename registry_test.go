@@ -0,0 +1,67 @@
+package patree
+
+import (
+	"testing"
+)
+
+func TestMatcherRegistry(t *testing.T) {
+	m := NewMatcherRegistry()
+	for matchType := range MatcherMap {
+		if m.Lookup(matchType) == nil {
+			t.Fatalf("built-in match type %s should be registered", matchType)
+		}
+	}
+
+	lower := RuneMatcherFunc(func(r rune) bool { return 'a' <= r && r <= 'z' })
+	cases := []struct {
+		matchType string
+		matcher   Matcher
+		err       error
+	}{
+		{"lower", lower, nil},
+		{"lower", lower, DuplicateMatchType},
+		{"int", lower, DuplicateMatchType},
+		{"", lower, InvalidMatchType},
+		{"lo:wer", lower, InvalidMatchType},
+		{"<lower>", lower, InvalidMatchType},
+		{"lower/case", lower, InvalidMatchType},
+		{"lower_2", lower, nil},
+		{"upper", nil, NilMatcher},
+	}
+
+	for _, tc := range cases {
+		if err := m.Register(tc.matchType, tc.matcher); err != tc.err {
+			t.Fatalf("Register(%q) should return %v. Got %v instead",
+				tc.matchType, tc.err, err)
+		}
+	}
+
+	if MatcherMap["lower"] != nil {
+		t.Fatal("registry should not modify MatcherMap")
+	}
+}
+
+func TestRouteMatcherRegistry(t *testing.T) {
+	m := NewMatcherRegistry()
+	err := m.Register("lower", RuneMatcherFunc(func(r rune) bool {
+		return 'a' <= r && r <= 'z'
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := NewRoute(m)
+	cases := []routeTestCase{
+		{"/users/<lower:name>", "/users/gopher", params{"name": "gopher"}},
+		{"/users/<lower:name>-<int:id>", "/users/gopher-10",
+			params{"name": "gopher", "id": "10"}},
+	}
+	execTests(mux, cases, t)
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("a Route without the registry should not know lower match type")
+		}
+	}()
+	(&Route{}).Get("/users/<lower:name>")
+}
@@ -0,0 +1,82 @@
+package patree
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// Router is a read-only request multiplexer compiled from a Route. A Route
+// works as the builder of Routers: registering patterns and handlers to the
+// Route doesn't affect Routers that are already compiled, so a Router can serve
+// requests while the Route is modified.
+type Router struct {
+	route *Route
+}
+
+// Compile returns a Router that has a snapshot of the handlers and patterns
+// registered to the route.
+func (r *Route) Compile() *Router {
+	return &Router{r.clone()}
+}
+
+// clone returns a copy of the route chain. Pattern routers and nested routes
+// are copied as well.
+func (r *Route) clone() *Route {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	switch f := r.f.(type) {
+	case *patternRouter:
+		c.f = f.clone()
+	case *Route:
+		c.f = f.clone()
+	}
+	c.next = r.next.clone()
+	return &c
+}
+
+// ServeHTTP implement http.Handler interface
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt.route.ServeHTTP(w, r)
+}
+
+// ServeHTTPContext implements Handler interface
+func (rt *Router) ServeHTTPContext(w http.ResponseWriter, r *http.Request, c *Context) {
+	rt.route.ServeHTTPContext(w, r, c)
+}
+
+// SwapRouter serves requests with the active Router, which can be replaced
+// atomically at any time. Requests are served without locks.
+type SwapRouter struct {
+	active atomic.Pointer[Router]
+}
+
+// NewSwapRouter returns a SwapRouter that serves requests with the Router.
+func NewSwapRouter(rt *Router) *SwapRouter {
+	s := &SwapRouter{}
+	s.active.Store(rt)
+	return s
+}
+
+// Router returns the active Router.
+func (s *SwapRouter) Router() *Router {
+	return s.active.Load()
+}
+
+// Swap replaces the active Router with the given Router, and returns the old
+// one. Requests that are already being served keep using the old Router.
+func (s *SwapRouter) Swap(rt *Router) *Router {
+	return s.active.Swap(rt)
+}
+
+// ServeHTTP implement http.Handler interface. It replies with 404 if there
+// is no active Router.
+func (s *SwapRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rt := s.active.Load()
+	if rt == nil {
+		http.NotFound(w, r)
+		return
+	}
+	rt.ServeHTTP(w, r)
+}
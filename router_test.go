@@ -0,0 +1,121 @@
+package patree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func newFeatureRoute() *Route {
+	mux := &Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Next(w, r)
+		if c.NotFound() {
+			http.NotFound(w, r)
+		}
+	})
+	return mux
+}
+
+func featureHandler(name string) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, c *Context) {
+		io.WriteString(w, name+c.Params["id"])
+	}
+}
+
+func serve(h http.Handler, urlStr string) *httptest.ResponseRecorder {
+	r, err := http.NewRequest("GET", urlStr, nil)
+	if err != nil {
+		panic(err)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestCompile(t *testing.T) {
+	mux := newFeatureRoute()
+	mux.Get("/foo/<int:id>", featureHandler("foo"))
+	router := mux.Compile()
+
+	mux.Get("/bar/<int:id>", featureHandler("bar"))
+	mux.Get("/foo/<int:id>/edit", featureHandler("edit"))
+
+	cases := []struct {
+		urlStr string
+		code   int
+		body   string
+	}{
+		{"/foo/1", 200, "foo1"},
+		{"/bar/1", 404, ""},
+		{"/foo/1/edit", 404, ""},
+	}
+	for _, tc := range cases {
+		w := serve(router, tc.urlStr)
+		if w.Code != tc.code {
+			t.Fatalf("%s should respond %d. Got %d instead", tc.urlStr,
+				tc.code, w.Code)
+		}
+		if tc.body != "" && w.Body.String() != tc.body {
+			t.Fatalf("%s should respond %s. Got %s instead", tc.urlStr,
+				tc.body, w.Body.String())
+		}
+	}
+
+	if w := serve(mux.Compile(), "/foo/1/edit"); w.Body.String() != "edit1" {
+		t.Fatal("compiled router should have patterns registered before")
+	}
+}
+
+func TestSwapRouter(t *testing.T) {
+	var s SwapRouter
+	if w := serve(&s, "/features/0"); w.Code != 404 {
+		t.Fatal("SwapRouter without Router should respond 404")
+	}
+
+	mux := newFeatureRoute()
+	mux.Get("/features/0/<int:id>", featureHandler("0-"))
+	s.Swap(mux.Compile())
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for n := 0; ; n++ {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				id := strconv.Itoa(n)
+				w := serve(&s, "/features/0/"+id)
+				if w.Code != 200 || w.Body.String() != "0-"+id {
+					t.Errorf("/features/0/%s responded %d %s", id, w.Code,
+						w.Body.String())
+					return
+				}
+			}
+		}()
+	}
+
+	for i := 1; i < 100; i++ {
+		name := fmt.Sprintf("%d-", i)
+		mux.Get(fmt.Sprintf("/features/%d/<int:id>", i), featureHandler(name))
+		old := s.Swap(mux.Compile())
+		if w := serve(old, fmt.Sprintf("/features/%d/1", i)); w.Code != 404 {
+			t.Fatal("old router should not have patterns registered after")
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	if w := serve(&s, "/features/99/1"); w.Body.String() != "99-1" {
+		t.Fatal("the last swapped router should be active")
+	}
+}
@@ -0,0 +1,245 @@
+package patree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// StaticOptions represents options of Route.Static.
+type StaticOptions struct {
+	// Index is the file served for directories. It's "index.html" if empty.
+	Index string
+
+	// Fallback is the file served instead of missing files, e.g. "index.html"
+	// of a single page application. Missing files call Context.Next if it's
+	// empty.
+	Fallback string
+
+	// Precompressed serves "name.br" or "name.gz" instead of "name" if it
+	// exists and the client accepts the encoding.
+	Precompressed bool
+
+	// Browse lists files of directories that have no index file. Such
+	// directories call Context.Next by default.
+	Browse bool
+}
+
+// Static registers handlers that serve files of fsys under the prefix for GET
+// and HEAD methods. For example, "/assets/js/app.js" is "js/app.js" of fsys
+// with prefix "/assets/". Responses have ETag and Last-Modified headers, and
+// conditional and range requests are supported. A request for a missing file
+// calls Context.Next, so that the following handlers can serve it.
+func (r *Route) Static(prefix string, fsys fs.FS, opts StaticOptions) {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	s := &staticServer{fsys, opts}
+	prefix = strings.TrimSuffix(prefix, "/") + "/"
+	r.Get(prefix, s.serve)
+	r.Get(prefix+"<path:filepath>", s.serve)
+}
+
+type staticServer struct {
+	fsys fs.FS
+	opts StaticOptions
+}
+
+// serve serves the file of the "filepath" param.
+func (s *staticServer) serve(w http.ResponseWriter, r *http.Request, c *Context) {
+	name := path.Clean("/" + c.Params["filepath"])[1:]
+	if name == "" {
+		name = "."
+	}
+
+	fi, err := fs.Stat(s.fsys, name)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		s.error(w, c, err)
+		return
+	}
+	if err == nil && fi.IsDir() {
+		s.serveDir(w, r, c, name)
+		return
+	}
+
+	if err == nil && s.serveFile(w, r, c, name) {
+		return
+	}
+	if s.opts.Fallback != "" && s.serveFile(w, r, c, s.opts.Fallback) {
+		return
+	}
+	c.Next(w, r)
+}
+
+// serveDir serves the index file or the listing of the directory.
+func (s *staticServer) serveDir(w http.ResponseWriter, r *http.Request, c *Context, name string) {
+	index := path.Join(name, s.opts.Index)
+	fi, err := fs.Stat(s.fsys, index)
+	hasIndex := err == nil && !fi.IsDir()
+	if !hasIndex && !s.opts.Browse {
+		c.Next(w, r)
+		return
+	}
+
+	// relative links of the directory need the trailing slash
+	if !strings.HasSuffix(r.URL.Path, "/") {
+		u := url.URL{Path: path.Base(r.URL.Path) + "/", RawQuery: r.URL.RawQuery}
+		http.Redirect(w, r, u.String(), http.StatusMovedPermanently)
+		return
+	}
+
+	if hasIndex && s.serveFile(w, r, c, index) {
+		return
+	}
+
+	entries, err := fs.ReadDir(s.fsys, name)
+	if err != nil {
+		s.error(w, c, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	io.WriteString(w, "<pre>\n")
+	for _, entry := range entries {
+		n := entry.Name()
+		if entry.IsDir() {
+			n += "/"
+		}
+		u := url.URL{Path: n}
+		fmt.Fprintf(w, "<a href=\"%s\">%s</a>\n", html.EscapeString(u.String()),
+			html.EscapeString(n))
+	}
+	io.WriteString(w, "</pre>\n")
+}
+
+// staticEncodings are encodings of precompressed files in the order of
+// preference.
+var staticEncodings = []struct{ ext, encoding string }{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+// serveFile serves the file, or its precompressed variant. It returns false if
+// the file doesn't exist or is a directory.
+func (s *staticServer) serveFile(w http.ResponseWriter, r *http.Request, c *Context, name string) bool {
+	if s.opts.Precompressed {
+		for _, enc := range staticEncodings {
+			if !acceptsEncoding(r, enc.encoding) {
+				continue
+			}
+			f, fi, err := openFile(s.fsys, name+enc.ext)
+			if err != nil {
+				continue
+			}
+			defer f.Close()
+
+			ctype := mime.TypeByExtension(path.Ext(name))
+			if ctype == "" {
+				ctype = "application/octet-stream"
+			}
+			w.Header().Set("Content-Type", ctype)
+			w.Header().Set("Content-Encoding", enc.encoding)
+			w.Header().Add("Vary", "Accept-Encoding")
+			s.serveContent(w, r, c, name, f, fi)
+			return true
+		}
+	}
+
+	f, fi, err := openFile(s.fsys, name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if s.opts.Precompressed {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	s.serveContent(w, r, c, name, f, fi)
+	return true
+}
+
+// serveContent serves the content of the file with ETag and Last-Modified
+// headers. A file without modification time has the ETag of its content.
+func (s *staticServer) serveContent(w http.ResponseWriter, r *http.Request, c *Context, name string, f fs.File, fi fs.FileInfo) {
+	rs, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			s.error(w, c, err)
+			return
+		}
+		rs = bytes.NewReader(data)
+	}
+
+	var etag string
+	if fi.ModTime().IsZero() {
+		h := sha256.New()
+		if _, err := io.Copy(h, rs); err != nil {
+			s.error(w, c, err)
+			return
+		}
+		if _, err := rs.Seek(0, io.SeekStart); err != nil {
+			s.error(w, c, err)
+			return
+		}
+		etag = fmt.Sprintf(`"%x"`, h.Sum(nil)[:16])
+	} else {
+		etag = `"` + strconv.FormatInt(fi.ModTime().UnixNano(), 36) + "-" +
+			strconv.FormatInt(fi.Size(), 36) + `"`
+	}
+	w.Header().Set("Etag", etag)
+	http.ServeContent(w, r, name, fi.ModTime(), rs)
+}
+
+// error sets the error to the context and responds 500.
+func (s *staticServer) error(w http.ResponseWriter, c *Context, err error) {
+	c.Err = err
+	http.Error(w, http.StatusText(http.StatusInternalServerError),
+		http.StatusInternalServerError)
+}
+
+// openFile opens the file of the name. It returns an error if the file is a
+// directory.
+func openFile(fsys fs.FS, name string) (fs.File, fs.FileInfo, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	if fi.IsDir() {
+		f.Close()
+		return nil, nil, fs.ErrNotExist
+	}
+	return f, fi, nil
+}
+
+// acceptsEncoding see if the Accept-Encoding header of the request accepts
+// the encoding.
+func acceptsEncoding(r *http.Request, encoding string) bool {
+	for _, v := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		coding, q, _ := strings.Cut(v, ";")
+		if strings.TrimSpace(coding) != encoding {
+			continue
+		}
+		q = strings.TrimSpace(q)
+		if strings.HasPrefix(q, "q=") {
+			if f, err := strconv.ParseFloat(q[2:], 64); err == nil && f == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
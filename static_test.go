@@ -0,0 +1,119 @@
+package patree
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func newStaticRoute(opts StaticOptions) *Route {
+	modTime := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"app.js":          {Data: []byte("console.log(1)"), ModTime: modTime},
+		"app.js.br":       {Data: []byte("br"), ModTime: modTime},
+		"app.js.gz":       {Data: []byte("gz"), ModTime: modTime},
+		"index.html":      {Data: []byte("<p>index</p>"), ModTime: modTime},
+		"docs/index.html": {Data: []byte("<p>docs</p>"), ModTime: modTime},
+		"img/logo.svg":    {Data: []byte("<svg></svg>")},
+	}
+
+	mux := &Route{}
+	mux.Static("/assets", fsys, opts)
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		io.WriteString(w, "next")
+	})
+	return mux
+}
+
+func TestStatic(t *testing.T) {
+	cases := []struct {
+		opts     StaticOptions
+		urlStr   string
+		header   map[string]string
+		code     int
+		body     string
+		response map[string]string
+	}{
+		{StaticOptions{}, "/assets/app.js", nil, 200, "console.log(1)",
+			map[string]string{"Last-Modified": "Wed, 01 Jan 2014 00:00:00 GMT",
+				"Etag": `"ajs2d59s7400-e"`}},
+		{StaticOptions{}, "/assets/app.js",
+			map[string]string{"If-None-Match": `"ajs2d59s7400-e"`}, 304, "", nil},
+		{StaticOptions{}, "/assets/app.js",
+			map[string]string{"Range": "bytes=0-6"}, 206, "console",
+			map[string]string{"Content-Range": "bytes 0-6/14"}},
+		{StaticOptions{}, "/assets/app.js", map[string]string{"Accept-Encoding": "br"},
+			200, "console.log(1)", map[string]string{"Content-Encoding": ""}},
+		{StaticOptions{Precompressed: true}, "/assets/app.js",
+			map[string]string{"Accept-Encoding": "gzip, br"}, 200, "br",
+			map[string]string{"Content-Encoding": "br", "Vary": "Accept-Encoding",
+				"Content-Type": "text/javascript; charset=utf-8"}},
+		{StaticOptions{Precompressed: true}, "/assets/app.js",
+			map[string]string{"Accept-Encoding": "gzip, br;q=0"}, 200, "gz",
+			map[string]string{"Content-Encoding": "gzip"}},
+		{StaticOptions{Precompressed: true}, "/assets/app.js", nil, 200,
+			"console.log(1)", map[string]string{"Vary": "Accept-Encoding"}},
+		{StaticOptions{}, "/assets/img/logo.svg", nil, 200, "<svg></svg>",
+			map[string]string{"Last-Modified": "",
+				"Etag": `"b12e0d83ce2357d80b89c57694814d0a"`}},
+		{StaticOptions{}, "/assets/", nil, 200, "<p>index</p>", nil},
+		{StaticOptions{}, "/assets/docs/", nil, 200, "<p>docs</p>", nil},
+		{StaticOptions{}, "/assets/docs?v=1", nil, 301,
+			"<a href=\"/assets/docs/?v=1\">Moved Permanently</a>.\n\n",
+			map[string]string{"Location": "/assets/docs/?v=1"}},
+		{StaticOptions{}, "/assets/../app.js", nil, 200, "console.log(1)", nil},
+		{StaticOptions{}, "/assets/img/", nil, 200, "next", nil},
+		{StaticOptions{Browse: true}, "/assets/img/", nil, 200,
+			"<pre>\n<a href=\"logo.svg\">logo.svg</a>\n</pre>\n", nil},
+		{StaticOptions{}, "/assets/missing.js", nil, 200, "next", nil},
+		{StaticOptions{Fallback: "index.html"}, "/assets/posts/10", nil, 200,
+			"<p>index</p>", nil},
+		{StaticOptions{Index: "none.html", Fallback: "none.html"}, "/assets/", nil,
+			200, "next", nil},
+	}
+
+	for _, tc := range cases {
+		r, err := http.NewRequest("GET", tc.urlStr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		newStaticRoute(tc.opts).ServeHTTP(w, r)
+
+		if w.Code != tc.code || w.Body.String() != tc.body {
+			t.Fatalf("%s should respond %d %q. Got %d %q instead", tc.urlStr,
+				tc.code, tc.body, w.Code, w.Body.String())
+		}
+		for k, v := range tc.response {
+			if w.Header().Get(k) != v {
+				t.Fatalf("%s should have header %s: %q. Got %q instead",
+					tc.urlStr, k, v, w.Header().Get(k))
+			}
+		}
+	}
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	cases := map[string]bool{
+		"":                  false,
+		"gzip":              true,
+		"deflate, gzip":     true,
+		"gzip;q=0.5":        true,
+		"gzip; q=0":         false,
+		"br, gzip;q=0, zip": false,
+		"gzipx":             false,
+	}
+	for v, expected := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", v)
+		if acceptsEncoding(r, "gzip") != expected {
+			t.Fatalf("%q should accept gzip: %v", v, expected)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package patree
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Trace is the record of how patterns are matched with a request.
+type Trace struct {
+	Method string
+	Path   string
+
+	// Steps are the visited entries in the order they are evaluated.
+	Steps []TraceStep
+
+	// Pattern and Params are the pattern and params of the handler that the
+	// request is routed to. Pattern is empty if no pattern matches.
+	Pattern string
+	Params  map[string]string
+}
+
+// TraceStep is an entry visited while matching a request.
+type TraceStep struct {
+	Entry *Entry
+	Depth int
+
+	// Input is the remaining url string that the entry is matched against.
+	Input string
+
+	// Offset is the length of Input that the entry matched, or -1 if it
+	// doesn't match. MatchStr is the matched string of match entries.
+	Offset   int
+	MatchStr string
+
+	// Handler reports whether the entry matched the whole Input and has a
+	// handler of the method, that is, the entry finally won.
+	Handler bool
+}
+
+// Explain matches the method and url with the patterns registered to the
+// route like Match, and returns the trace of the visited entries.
+func (r *Route) Explain(method, urlStr string) *Trace {
+	t := &Trace{Method: method, Path: urlStr}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return t
+	}
+	for route := r; route != nil; route = route.next {
+		p, ok := route.f.(*patternRouter)
+		if !ok {
+			continue
+		}
+		if h, _ := p.match(method, u, t); h != nil {
+			break
+		}
+	}
+	return t
+}
+
+// String returns the human readable trace. Each line shows a visited entry,
+// its match type, the input, and the offset with the matched string.
+func (t *Trace) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", t.Method, t.Path)
+	for _, step := range t.Steps {
+		e := step.Entry
+		pat := e.pattern
+		if pat == "" {
+			pat = "(root)"
+		}
+		kind := e.matchType
+		if kind == "" {
+			kind = "static"
+		}
+		fmt.Fprintf(&b, "%s%s [%s] %q -> %d", strings.Repeat("  ", step.Depth),
+			pat, kind, step.Input, step.Offset)
+		if step.MatchStr != "" {
+			fmt.Fprintf(&b, " %q", step.MatchStr)
+		}
+		if step.Handler {
+			b.WriteString(" handler")
+		}
+		b.WriteString("\n")
+	}
+
+	if t.Pattern == "" {
+		b.WriteString("no pattern matches\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "matched %q", t.Pattern)
+	names := make([]string, 0, len(t.Params))
+	for name := range t.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, " %s=%q", name, t.Params[name])
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// ExplainHeader returns a middleware for debugging routes. If a request has
+// the header, the middleware responds with the trace of how the request is
+// matched instead of serving it. It must not be used where clients can't be
+// trusted since the trace reveals registered patterns.
+func ExplainHeader(header string) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, c *Context) {
+		if r.Header.Get(header) == "" {
+			c.Next(w, r)
+			return
+		}
+		t := c.route.Explain(r.Method, r.URL.RequestURI())
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		io.WriteString(w, t.String())
+	}
+}
+
+// explain runs the same matching as exec, and records visited entries to the
+// trace.
+func (e *Entry) explain(t *Trace, depth int, method, urlStr string) (*Route, []string) {
+	i := len(t.Steps)
+	t.Steps = append(t.Steps, TraceStep{Entry: e, Depth: depth, Input: urlStr})
+
+	offset, matchStr := e.matchPrefix(urlStr)
+	t.Steps[i].Offset, t.Steps[i].MatchStr = offset, matchStr
+	if offset == -1 {
+		return nil, nil
+	}
+
+	var params []string
+	if e.matcher != nil {
+		params = []string{e.name, matchStr}
+	}
+
+	// finish parsing
+	if len(urlStr) == offset {
+		h := e.GetHandler(method)
+		t.Steps[i].Handler = h != nil
+		if h == nil {
+			return nil, nil
+		}
+		return h, params
+	}
+
+	for _, entry := range e.entries {
+		h, childParams := entry.explain(t, depth+1, method, urlStr[offset:])
+		if h != nil {
+			return h, append(childParams, params...)
+		}
+	}
+	return nil, nil
+}
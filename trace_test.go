@@ -0,0 +1,100 @@
+package patree
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	mux := &Route{}
+	mux.Get("/posts/<int:post_id>", foobar)
+	mux.Get("/posts/2014", foobar)
+	mux.Use(foobar)
+	mux.Post("/posts/<slug>", foobar)
+
+	cases := []struct {
+		method  string
+		urlStr  string
+		pattern string
+		steps   []string
+	}{
+		{"GET", "/posts/2014", "/posts/2014", []string{
+			`(root) [static] "/posts/2014" -> 0`,
+			`  /posts/ [static] "/posts/2014" -> 7`,
+			`    2014 [static] "2014" -> 4 handler`,
+		}},
+		{"GET", "/posts/10", "/posts/<int:post_id>", []string{
+			`(root) [static] "/posts/10" -> 0`,
+			`  /posts/ [static] "/posts/10" -> 7`,
+			`    2014 [static] "10" -> -1`,
+			`    <int:post_id> [int] "10" -> 2 "10" handler`,
+		}},
+		{"POST", "/posts/10", "/posts/<slug>", []string{
+			`(root) [static] "/posts/10" -> 0`,
+			`  /posts/ [static] "/posts/10" -> 7`,
+			`    2014 [static] "10" -> -1`,
+			`    <int:post_id> [int] "10" -> 2 "10"`,
+			`(root) [static] "/posts/10" -> 0`,
+			`  /posts/ [static] "/posts/10" -> 7`,
+			`    <slug> [default] "10" -> 2 "10" handler`,
+		}},
+	}
+
+	for _, tc := range cases {
+		trace := mux.Explain(tc.method, tc.urlStr)
+		pat, p, _ := mux.Match(tc.method, tc.urlStr)
+		if trace.Pattern != tc.pattern || trace.Pattern != pat {
+			t.Fatalf("%s %s should match %s. Got %s instead", tc.method,
+				tc.urlStr, tc.pattern, trace.Pattern)
+		}
+		for k, v := range p {
+			if trace.Params[k] != v {
+				t.Fatalf("%s %s should have params %v. Got %v instead",
+					tc.method, tc.urlStr, p, trace.Params)
+			}
+		}
+
+		lines := strings.Split(trace.String(), "\n")
+		lines = lines[1 : len(lines)-2]
+		if strings.Join(lines, "\n") != strings.Join(tc.steps, "\n") {
+			t.Fatalf("%s %s should visit\n%s\nGot\n%s\ninstead", tc.method,
+				tc.urlStr, strings.Join(tc.steps, "\n"), strings.Join(lines, "\n"))
+		}
+	}
+
+	trace := mux.Explain("DELETE", "/posts/10")
+	if trace.Pattern != "" || !strings.HasSuffix(trace.String(), "no pattern matches\n") {
+		t.Fatalf("DELETE /posts/10 should not match. Got %q instead", trace.Pattern)
+	}
+}
+
+func TestContextTrace(t *testing.T) {
+	var trace *Trace
+	mux := &Route{}
+	mux.Use(func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.Trace = &Trace{Method: r.Method, Path: r.URL.Path}
+		c.Next(w, r)
+		trace = c.Trace
+	})
+	mux.Use(ExplainHeader("X-Patree-Explain"))
+	mux.Get("/posts/<int:id>", featureHandler("post"))
+
+	w := serve(mux, "/posts/10")
+	if w.Body.String() != "post10" {
+		t.Fatalf("/posts/10 should be served. Got %q instead", w.Body.String())
+	}
+	if trace.Pattern != "/posts/<int:id>" || trace.Params["id"] != "10" ||
+		len(trace.Steps) != 3 {
+		t.Fatalf("request should be traced. Got\n%s\ninstead", trace)
+	}
+
+	r, _ := http.NewRequest("GET", "/posts/10", nil)
+	r.Header.Set("X-Patree-Explain", "1")
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, r)
+	if !strings.HasSuffix(rec.Body.String(), "matched \"/posts/<int:id>\" id=\"10\"\n") {
+		t.Fatalf("request should be explained. Got\n%s\ninstead", rec.Body.String())
+	}
+}
@@ -0,0 +1,302 @@
+package patree
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// InvalidTraceparent is returned by ParseTraceparent when the header value
+// isn't a valid traceparent of W3C Trace Context.
+var InvalidTraceparent = errors.New("Invalid traceparent")
+
+// SpanContext identifies a span of a distributed trace. It's propagated by the
+// traceparent and tracestate headers of W3C Trace Context.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+	Flags   byte
+
+	// TraceState is the value of the tracestate header, which is propagated
+	// as is.
+	TraceState string
+}
+
+// ParseTraceparent parses the value of a traceparent header, e.g.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01". Values of future
+// versions are parsed as version "00" as the specification requires.
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return sc, InvalidTraceparent
+	}
+	version := s[:2]
+	if !isLowerHex(version) || version == "ff" ||
+		(version == "00" && len(s) != 55) || (len(s) > 55 && s[55] != '-') {
+		return sc, InvalidTraceparent
+	}
+
+	var flags [1]byte
+	for _, f := range []struct {
+		dst []byte
+		src string
+	}{
+		{sc.TraceID[:], s[3:35]},
+		{sc.SpanID[:], s[36:52]},
+		{flags[:], s[53:55]},
+	} {
+		if !isLowerHex(f.src) {
+			return sc, InvalidTraceparent
+		}
+		hex.Decode(f.dst, []byte(f.src))
+	}
+	sc.Flags = flags[0]
+	if !sc.IsValid() {
+		return sc, InvalidTraceparent
+	}
+	return sc, nil
+}
+
+// IsValid see if neither the trace ID nor the span ID is zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Sampled see if the sampled flag is set.
+func (sc SpanContext) Sampled() bool {
+	return sc.Flags&1 == 1
+}
+
+// Traceparent returns the value of the traceparent header of the span.
+func (sc SpanContext) Traceparent() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" +
+		hex.EncodeToString(sc.SpanID[:]) + "-" + hex.EncodeToString([]byte{sc.Flags})
+}
+
+// ChildSpanContext returns a new span context of a child span of the parent.
+// It starts a new sampled trace if the parent isn't valid.
+func ChildSpanContext(parent SpanContext) SpanContext {
+	sc := parent
+	if !parent.IsValid() {
+		sc = SpanContext{Flags: 1}
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	return sc
+}
+
+// Tracer starts spans of requests. Implement it to plug in a tracing library
+// such as OpenTelemetry.
+type Tracer interface {
+	// Start starts a span that is a child of the parent, which is invalid if
+	// the request has no traceparent. The returned context is passed to the
+	// following handlers by the request.
+	Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SpanContext() SpanContext
+	SetName(name string)
+	SetAttribute(key string, value interface{})
+
+	// End ends the span with the status code of the response and the error of
+	// Context.Err, which may be nil.
+	End(status int, err error)
+}
+
+// TracingOptions represents options of Tracing.
+type TracingOptions struct {
+	// Tracer starts spans of requests. Spans aren't recorded if it's nil,
+	// but their span contexts are still propagated.
+	Tracer Tracer
+
+	// RequestIDHeader is the header of request IDs. It's "X-Request-ID" if
+	// empty.
+	RequestIDHeader string
+
+	// NewRequestID returns a new request ID. Request IDs are random hex
+	// strings if it's nil.
+	NewRequestID func() string
+}
+
+// Tracing returns a middleware that propagates request IDs and W3C Trace
+// Context. The request ID of the request header is used if it's a printable
+// ASCII string of up to 128 characters, and a new one is assigned otherwise.
+// A span is started as a child of the traceparent of the request, and is
+// renamed to the method and the matched pattern, e.g. "GET /posts/<int:id>",
+// when a pattern matches. The request ID and the span context are stored on
+// the Context, and are set to the response headers. Use it before any other
+// handler.
+func Tracing(opts TracingOptions) HandlerFunc {
+	tracer := opts.Tracer
+	if tracer == nil {
+		tracer = noopTracer{}
+	}
+	header := opts.RequestIDHeader
+	if header == "" {
+		header = "X-Request-ID"
+	}
+	newRequestID := opts.NewRequestID
+	if newRequestID == nil {
+		newRequestID = randomRequestID
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, c *Context) {
+		c.requestID = r.Header.Get(header)
+		if !isValidRequestID(c.requestID) {
+			c.requestID = newRequestID()
+		}
+
+		parent, err := ParseTraceparent(r.Header.Get("traceparent"))
+		if err == nil {
+			parent.TraceState = strings.Join(r.Header.Values("tracestate"), ",")
+		}
+		ctx, span := tracer.Start(r.Context(), r.Method, parent)
+		c.spanContext = span.SpanContext()
+		c.OnMatch(func(c *Context) {
+			span.SetName(r.Method + " " + c.Pattern())
+			span.SetAttribute("http.route", c.Pattern())
+		})
+
+		h := w.Header()
+		h.Set(header, c.requestID)
+		h.Set("traceparent", c.spanContext.Traceparent())
+		if c.spanContext.TraceState != "" {
+			h.Set("tracestate", c.spanContext.TraceState)
+		}
+
+		sw := c.StatusWriter(w)
+		defer func() {
+			status := sw.Status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.End(status, c.Err)
+		}()
+		c.Next(sw, r.WithContext(ctx))
+	}
+}
+
+// RequestID returns the request ID of the request. It returns an empty string
+// unless Tracing handles the request.
+func (c *Context) RequestID() string {
+	return c.requestID
+}
+
+// SpanContext returns the span context of the request. It isn't valid unless
+// Tracing handles the request.
+func (c *Context) SpanContext() SpanContext {
+	return c.spanContext
+}
+
+// isValidRequestID see if the request ID consists of up to 128 printable ASCII
+// characters, so that it can be logged safely.
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func randomRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func isLowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !('0' <= s[i] && s[i] <= '9' || 'a' <= s[i] && s[i] <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// noopTracer starts spans that aren't recorded.
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	return ctx, noopSpan{ChildSpanContext(parent)}
+}
+
+type noopSpan struct {
+	sc SpanContext
+}
+
+func (s noopSpan) SpanContext() SpanContext                 { return s.sc }
+func (noopSpan) SetName(name string)                        {}
+func (noopSpan) SetAttribute(key string, value interface{}) {}
+func (noopSpan) End(status int, err error)                  {}
+
+// MemoryTracer is a Tracer that records ended spans in memory, e.g. for tests.
+// The zero value is ready to use.
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []*MemorySpan
+}
+
+// Start starts a MemorySpan.
+func (t *MemoryTracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	return ctx, &MemorySpan{
+		Name:       name,
+		Context:    ChildSpanContext(parent),
+		Parent:     parent,
+		Attributes: make(map[string]interface{}),
+		tracer:     t,
+	}
+}
+
+// Spans returns the ended spans in the order of ending.
+func (t *MemoryTracer) Spans() []*MemorySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*MemorySpan(nil), t.spans...)
+}
+
+// MemorySpan is a span of MemoryTracer.
+type MemorySpan struct {
+	Name       string
+	Context    SpanContext
+	Parent     SpanContext
+	Attributes map[string]interface{}
+
+	// Status and Err are passed to End.
+	Status int
+	Err    error
+
+	tracer *MemoryTracer
+}
+
+// SpanContext returns the span context of the span.
+func (s *MemorySpan) SpanContext() SpanContext {
+	return s.Context
+}
+
+// SetName sets the name of the span.
+func (s *MemorySpan) SetName(name string) {
+	s.Name = name
+}
+
+// SetAttribute sets the attribute of the span.
+func (s *MemorySpan) SetAttribute(key string, value interface{}) {
+	s.Attributes[key] = value
+}
+
+// End records the span to its tracer.
+func (s *MemorySpan) End(status int, err error) {
+	s.Status, s.Err = status, err
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+	s.tracer.spans = append(s.tracer.spans, s)
+}
@@ -0,0 +1,109 @@
+package patree
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	cases := map[string]bool{
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":     true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00":     true,
+		"01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo": true,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-foo": false,
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01":     false,
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01":     false,
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01":     false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01":     false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7":        false,
+		"": false,
+	}
+	for s, valid := range cases {
+		sc, err := ParseTraceparent(s)
+		if valid != (err == nil) {
+			t.Fatalf("%q should be valid: %v. Got %v instead", s, valid, err)
+		}
+		if valid && s[:2] == "00" && sc.Traceparent() != s {
+			t.Fatalf("%q should be formatted back. Got %s instead", s,
+				sc.Traceparent())
+		}
+	}
+}
+
+func TestTracing(t *testing.T) {
+	tracer := &MemoryTracer{}
+	mux := &Route{}
+	mux.Use(Tracing(TracingOptions{
+		Tracer:       tracer,
+		NewRequestID: func() string { return "generated" },
+	}))
+	mux.Get("/posts/<int:id>", func(w http.ResponseWriter, r *http.Request, c *Context) {
+		if c.RequestID() == "" || !c.SpanContext().IsValid() {
+			t.Fatalf("context should have the request ID and span context")
+		}
+		c.Err = errors.New("post")
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	cases := []struct {
+		urlStr    string
+		header    map[string]string
+		requestID string
+		name      string
+		status    int
+		child     bool
+	}{
+		{"/posts/10", map[string]string{"X-Request-ID": "abc", "traceparent": parent,
+			"tracestate": "foo=bar"}, "abc", "GET /posts/<int:id>", 500, true},
+		{"/posts/10", map[string]string{"X-Request-ID": "a b",
+			"traceparent": "invalid"}, "generated", "GET /posts/<int:id>", 500, false},
+		{"/users/10", nil, "generated", "GET", 200, false},
+	}
+	for i, tc := range cases {
+		r := httptest.NewRequest("GET", tc.urlStr, nil)
+		for k, v := range tc.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, r)
+
+		spans := tracer.Spans()
+		if len(spans) != i+1 {
+			t.Fatalf("%s should end a span. Got %d spans", tc.urlStr, len(spans))
+		}
+		span := spans[i]
+		if span.Name != tc.name || span.Status != tc.status {
+			t.Fatalf("%s should have span %s %d. Got %s %d instead", tc.urlStr,
+				tc.name, tc.status, span.Name, span.Status)
+		}
+		if id := w.Header().Get("X-Request-ID"); id != tc.requestID {
+			t.Fatalf("%s should respond request ID %s. Got %s instead",
+				tc.urlStr, tc.requestID, id)
+		}
+		if tp := w.Header().Get("traceparent"); tp != span.Context.Traceparent() {
+			t.Fatalf("%s should respond traceparent %s. Got %s instead",
+				tc.urlStr, span.Context.Traceparent(), tp)
+		}
+
+		if !tc.child {
+			if span.Parent.IsValid() || !span.Context.Sampled() {
+				t.Fatalf("%s should start a sampled trace. Got %+v", tc.urlStr,
+					span)
+			}
+			continue
+		}
+		if span.Parent.Traceparent() != parent ||
+			span.Context.TraceID != span.Parent.TraceID ||
+			span.Context.SpanID == span.Parent.SpanID {
+			t.Fatalf("%s should be a child of %s. Got %s", tc.urlStr, parent,
+				span.Context.Traceparent())
+		}
+		if ts := w.Header().Get("tracestate"); ts != "foo=bar" {
+			t.Fatalf("%s should propagate tracestate. Got %q instead",
+				tc.urlStr, ts)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package patree
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusWriter wraps a http.ResponseWriter to record the status code and the
+// number of bytes written.
+type StatusWriter struct {
+	http.ResponseWriter
+
+	// Status is the status code of the response, or 0 if nothing has been
+	// written yet.
+	Status int
+
+	// Bytes is the number of bytes of the written body.
+	Bytes int64
+}
+
+// WriteHeader records the status code. Informational status codes aren't
+// recorded.
+func (w *StatusWriter) WriteHeader(code int) {
+	if w.Status == 0 && code >= 200 {
+		w.Status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the number of bytes written, and 200 as the status code if
+// WriteHeader hasn't been called.
+func (w *StatusWriter) Write(b []byte) (int, error) {
+	if w.Status == 0 {
+		w.Status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.Bytes += int64(n)
+	return n, err
+}
+
+// Written see if the status code has been written.
+func (w *StatusWriter) Written() bool {
+	return w.Status != 0
+}
+
+// Flush implements http.Flusher if the underlying ResponseWriter does.
+func (w *StatusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.Status == 0 {
+			w.Status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the underlying ResponseWriter does.
+func (w *StatusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("http.Hijacker is not implemented")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController.
+func (w *StatusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// StatusWriter returns the StatusWriter that tracks the response of the
+// request. It wraps w unless w is a StatusWriter, and the first StatusWriter
+// tracks the response for Written, so that middlewares can share it.
+func (c *Context) StatusWriter(w http.ResponseWriter) *StatusWriter {
+	sw, ok := w.(*StatusWriter)
+	if !ok {
+		sw = &StatusWriter{ResponseWriter: w}
+	}
+	if c.writer == nil {
+		c.writer = sw
+	}
+	return sw
+}
+
+// Written see if the response has been written. It only knows responses that
+// are written through the StatusWriter of the context, e.g. the one of the
+// Recover, AccessLog or Metrics middleware.
+func (c *Context) Written() bool {
+	return c.writer != nil && c.writer.Written()
+}